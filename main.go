@@ -1,14 +1,176 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
 
 	gui "manalyzer/src"
 )
 
 func main() {
+	home := flag.String("home", "", "directory for config and logs (overrides $MANALYZER_HOME)")
+	debugMatch := flag.String("debug-match", "", "print a round-by-round stats dump of this demo and exit (needs --steamid)")
+	steamID := flag.Uint64("steamid", 0, "SteamID64 of the player for --debug-match")
+	gatherReport := flag.String("gather-report", "", "parse all demos, write a JSON report to this path and exit (non-zero if any demo failed)")
+	demoDir := flag.String("demos", "", "demo directory for --gather-report and --leaderboard (default: the saved demo base path)")
+	leaderboard := flag.String("leaderboard", "", "analyze the demos for the saved players, print them ranked by this overall metric (e.g. adr) as CSV and exit")
+	markdown := flag.Bool("markdown", false, "print --leaderboard as a Markdown table")
+	clearCache := flag.Bool("clear-cache", false, "delete the on-disk demo cache and exit")
+	serve := flag.String("serve", "", "serve the analysis HTTP API on this address (e.g. :8080, bound to localhost) instead of the UI")
+	flag.Parse()
+
+	if *debugMatch != "" {
+		if err := dumpMatch(*debugMatch, *steamID); err != nil {
+			log.Fatalf("Debug dump failed: %v", err)
+		}
+		return
+	}
+
+	if *home != "" {
+		gui.SetHomeDir(*home)
+	}
+
+	// Config errors are reported again by the UI, which loads it too
+	cfg, _ := gui.LoadConfig()
+	gui.SetLogLevel(cfg.Preferences.LogLevel)
+	gui.SetLogMaxSize(cfg.Preferences.LogMaxSizeMB)
+	if err := gui.InitLogger(cfg.Preferences.LogTarget); err != nil {
+		log.Printf("Logging disabled: %v", err)
+	}
+	defer gui.CloseLogger()
+	gui.SetDemoCacheEnabled(cfg.Preferences.DemoCache)
+
+	if *clearCache {
+		stats, err := gui.DemoCacheStats()
+		if err != nil {
+			log.Fatalf("Cannot read demo cache: %v", err)
+		}
+		if err := gui.ClearDemoCache(); err != nil {
+			log.Fatalf("%v", err)
+		}
+		fmt.Printf("Removed %d cached demos (%.1f MB) from %s\n", stats.Entries, float64(stats.Bytes)/(1<<20), stats.Dir)
+		return
+	}
+
+	if *gatherReport != "" {
+		dir := *demoDir
+		if dir == "" {
+			dir = cfg.BasePath
+		}
+		failed, err := writeGatherReport(dir, *gatherReport, cfg.Preferences)
+		if err != nil {
+			log.Fatalf("Gather report failed: %v", err)
+		}
+		if failed > 0 {
+			gui.CloseLogger()
+			log.Printf("%d demos failed to parse, see %s", failed, *gatherReport)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *leaderboard != "" {
+		dir := *demoDir
+		if dir == "" {
+			dir = cfg.BasePath
+		}
+		if err := printLeaderboard(dir, *leaderboard, *markdown, cfg); err != nil {
+			log.Fatalf("Leaderboard failed: %v", err)
+		}
+		return
+	}
+
+	if *serve != "" {
+		fmt.Printf("Serving the analysis API on %s, press Ctrl+C to stop\n", *serve)
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		if err := gui.Serve(ctx, *serve, cfg.Preferences); err != nil {
+			log.Fatalf("API server failed: %v", err)
+		}
+		return
+	}
+
 	ui := gui.New()
 	if err := ui.Start(); err != nil {
 		log.Fatalf("UI error %v", err)
 	}
 }
+
+// dumpMatch parses one demo and prints the player's per-round stats to stdout.
+func dumpMatch(path string, steamID uint64) error {
+	if steamID == 0 {
+		return fmt.Errorf("--steamid is required with --debug-match")
+	}
+	match, err := gui.GatherDemo(path)
+	if err != nil {
+		return err
+	}
+	return gui.DumpMatchStats(match, steamID, os.Stdout)
+}
+
+// writeGatherReport parses every demo in dir as prefs' demo source and writes
+// the gather report to path, returning the number of demos that failed.
+func writeGatherReport(dir, path string, prefs gui.Preferences) (int, error) {
+	if dir == "" {
+		return 0, fmt.Errorf("no demo directory: pass --demos or set a base path in the UI")
+	}
+	_, report, err := gui.GatherLatestDemosWithReport(context.Background(), dir, 0, prefs.ParserSource())
+	if report == nil {
+		return 0, err
+	}
+	if err := gui.WriteGatherReport(report, path); err != nil {
+		return 0, err
+	}
+	return report.Count(gui.GatherStatusFailed), nil
+}
+
+// printLeaderboard analyzes the demos in dir for cfg's players and prints
+// them ranked by metric to stdout.
+func printLeaderboard(dir, metric string, markdown bool, cfg *gui.Config) error {
+	if dir == "" {
+		return fmt.Errorf("no demo directory: pass --demos or set a base path in the UI")
+	}
+	// Check the metric before spending time on parsing
+	if !slices.Contains(gui.LeaderboardMetrics(), metric) {
+		return fmt.Errorf("unknown metric %q (want one of %s)", metric, strings.Join(gui.LeaderboardMetrics(), ", "))
+	}
+
+	ctx := context.Background()
+	var steamIDs []string
+	for _, player := range cfg.Players {
+		if player.SteamID64 == "" {
+			continue
+		}
+		steamID, err := gui.ResolveSteamIDWithKey(ctx, player.SteamID64, cfg.SteamAPIKey)
+		if err != nil {
+			return err
+		}
+		steamIDs = append(steamIDs, steamID)
+	}
+	if len(steamIDs) == 0 {
+		return fmt.Errorf("no players: add them in the UI first")
+	}
+
+	matches, _, err := gui.GatherLatestDemosWithReport(ctx, dir, 0, cfg.Preferences.ParserSource())
+	if len(matches) == 0 {
+		return err
+	}
+	if err != nil {
+		log.Printf("Some demos were skipped: %v", err)
+	}
+	result, err := gui.ProcessMatches(ctx, matches, steamIDs, cfg.Preferences, [2]int{})
+	if err != nil {
+		return err
+	}
+	if markdown {
+		return gui.ExportLeaderboardMarkdown(result, metric, os.Stdout)
+	}
+	return gui.ExportLeaderboard(result, metric, os.Stdout)
+}
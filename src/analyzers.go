@@ -0,0 +1,53 @@
+package manalyzer
+
+import (
+	"sync"
+
+	"github.com/akiver/cs-demo-analyzer/pkg/api"
+)
+
+// MatchAnalyzer is a custom per-match analysis step.
+//
+// ProcessMatches calls every registered analyzer once per match, after the
+// built-in stats for that match have been merged. players maps the SteamID64
+// of each tracked player to their PlayerStats; players absent from the match
+// are included too, so check match.PlayersBySteamID. OverallStats is not yet
+// computed when analyzers run. Analyzers run sequentially on the calling
+// goroutine and must not retain match or players after returning. A panicking
+// analyzer is recovered and logged without affecting the others.
+type MatchAnalyzer func(match *api.Match, players map[uint64]*PlayerStats)
+
+var (
+	matchAnalyzersMu sync.RWMutex
+	matchAnalyzers   []MatchAnalyzer
+)
+
+// RegisterMatchAnalyzer adds fn to the analyzers run by ProcessMatches.
+func RegisterMatchAnalyzer(fn MatchAnalyzer) {
+	if fn == nil {
+		return
+	}
+	matchAnalyzersMu.Lock()
+	defer matchAnalyzersMu.Unlock()
+	matchAnalyzers = append(matchAnalyzers, fn)
+}
+
+// runMatchAnalyzers calls each registered analyzer for match.
+func runMatchAnalyzers(match *api.Match, players map[uint64]*PlayerStats) {
+	matchAnalyzersMu.RLock()
+	analyzers := append([]MatchAnalyzer(nil), matchAnalyzers...)
+	matchAnalyzersMu.RUnlock()
+
+	for i, analyzer := range analyzers {
+		runMatchAnalyzer(i, analyzer, match, players)
+	}
+}
+
+func runMatchAnalyzer(index int, analyzer MatchAnalyzer, match *api.Match, players map[uint64]*PlayerStats) {
+	defer func() {
+		if r := recover(); r != nil {
+			LogError("match analyzer %d panicked on %s: %v", index, match.DemoFileName, r)
+		}
+	}()
+	analyzer(match, players)
+}
@@ -0,0 +1,48 @@
+package manalyzer
+
+import (
+	"maps"
+	"testing"
+
+	"github.com/akiver/cs-demo-analyzer/pkg/api"
+)
+
+// useTestAnalyzers restores the registered analyzers when the test ends.
+func useTestAnalyzers(t *testing.T) {
+	t.Helper()
+	matchAnalyzersMu.Lock()
+	saved := matchAnalyzers
+	matchAnalyzersMu.Unlock()
+	t.Cleanup(func() {
+		matchAnalyzersMu.Lock()
+		matchAnalyzers = saved
+		matchAnalyzersMu.Unlock()
+	})
+}
+
+func TestRegisterMatchAnalyzer(t *testing.T) {
+	useTestAnalyzers(t)
+
+	// Counts each player's knife kills in a side channel; a panicking
+	// analyzer before it must not stop it.
+	knifeKills := make(map[string]int)
+	RegisterMatchAnalyzer(func(*api.Match, map[uint64]*PlayerStats) { panic("broken analyzer") })
+	RegisterMatchAnalyzer(func(match *api.Match, players map[uint64]*PlayerStats) {
+		for _, kill := range match.Kills {
+			if playerStats := players[kill.KillerSteamID64]; playerStats != nil && kill.WeaponName == "Knife" {
+				knifeKills[playerStats.PlayerName]++
+			}
+		}
+	})
+
+	first, second := newTestMatch("de_mirage", 2), newTestMatch("de_nuke", 2)
+	addKill(first, 1, 300, alice, carol).WeaponName = "Knife"
+	addKill(first, 2, 300, alice, carol).WeaponName = "AK-47"
+	addKill(second, 1, 300, alice, dave).WeaponName = "Knife"
+	addKill(second, 1, 400, carol, bob).WeaponName = "Knife" // Not tracked
+	processTestMatches(t, []*api.Match{first, second}, alice, bob)
+
+	if want := map[string]int{"alice": 2}; !maps.Equal(knifeKills, want) {
+		t.Errorf("knife kills = %v, want %v", knifeKills, want)
+	}
+}
@@ -0,0 +1,150 @@
+package manalyzer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+const (
+	browserPage   = "browse"
+	browserWidth  = 70
+	browserHeight = 20
+)
+
+// listDirectory returns the names of the subdirectories of dir and of its
+// files with extension ext (ignoring case), both sorted. An empty ext lists
+// no files.
+func listDirectory(dir, ext string) (dirs, files []string, err error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, entry := range entries {
+		switch {
+		case entry.IsDir():
+			dirs = append(dirs, entry.Name())
+		case ext != "" && strings.EqualFold(filepath.Ext(entry.Name()), ext):
+			files = append(files, entry.Name())
+		}
+	}
+	sort.Strings(dirs)
+	sort.Strings(files)
+	return dirs, files, nil
+}
+
+// browserStartDir picks where the browser opens: path if it exists, else its
+// closest existing parent, else the home or working directory.
+func browserStartDir(path string) string {
+	if path != "" {
+		dir := filepath.Clean(path)
+		for {
+			if info, err := os.Stat(dir); err == nil && info.IsDir() {
+				return dir
+			}
+			parent := filepath.Dir(dir)
+			if parent == dir {
+				break
+			}
+			dir = parent
+		}
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		return home
+	}
+	if wd, err := os.Getwd(); err == nil {
+		return wd
+	}
+	return string(filepath.Separator)
+}
+
+// showDirectoryBrowser opens a dialog titled title to pick a directory,
+// starting at start. onSelect is called with the chosen directory; ESC
+// closes without choosing.
+func (u *UI) showDirectoryBrowser(title, start string, onSelect func(dir string)) {
+	u.showBrowser(title, start, "", onSelect)
+}
+
+// showFileBrowser is showDirectoryBrowser for picking a file with extension
+// ext. start may be a directory or a file in it.
+func (u *UI) showFileBrowser(title, start, ext string, onSelect func(path string)) {
+	u.showBrowser(title, start, ext, onSelect)
+}
+
+// showBrowser implements showDirectoryBrowser, or showFileBrowser when ext
+// is set.
+func (u *UI) showBrowser(title, start, ext string, onSelect func(path string)) {
+	list := tview.NewList().ShowSecondaryText(false)
+	status := tview.NewTextView().SetDynamicColors(true)
+
+	layout := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(list, 0, 1, true).
+		AddItem(status, 2, 0, false)
+	layout.SetBorder(true).SetTitle(title).SetTitleAlign(tview.AlignLeft)
+
+	closeBrowser := func() {
+		u.Pages.RemovePage(browserPage)
+		u.App.SetFocus(u.form)
+	}
+
+	var show func(dir string)
+	show = func(dir string) {
+		dirs, files, err := listDirectory(dir, ext)
+		if err != nil {
+			// Stay where we are and report why the folder can't be opened
+			status.SetText(fmt.Sprintf("%s\n%s", tview.Escape(dir), errorText(fmt.Sprintf("Cannot open: %v", err))))
+			return
+		}
+
+		list.Clear()
+		if ext == "" {
+			list.AddItem(tview.Escape("[ Use this folder ]"), "", 0, func() {
+				closeBrowser()
+				onSelect(dir)
+			})
+		}
+		if parent := filepath.Dir(dir); parent != dir {
+			list.AddItem("..", "", 0, func() { show(parent) })
+		}
+		for _, name := range dirs {
+			sub := filepath.Join(dir, name)
+			list.AddItem(tview.Escape(name+string(filepath.Separator)), "", 0, func() { show(sub) })
+		}
+		for _, name := range files {
+			path := filepath.Join(dir, name)
+			list.AddItem(tview.Escape(name), "", 0, func() {
+				closeBrowser()
+				onSelect(path)
+			})
+		}
+		status.SetText(tview.Escape(dir) + "\nEnter: open  ESC: cancel")
+	}
+
+	list.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyESC {
+			closeBrowser()
+			return nil
+		}
+		return event
+	})
+
+	show(browserStartDir(start))
+
+	u.Pages.AddPage(browserPage, centered(layout, browserWidth, browserHeight), true, true)
+}
+
+// errorText escapes text for a dynamic-color view, in red when colors are
+// enabled.
+func errorText(text string) string {
+	if !colorsEnabled {
+		return tview.Escape(text)
+	}
+	return "[red]" + tview.Escape(text) + "[-]"
+}
@@ -0,0 +1,35 @@
+package manalyzer
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestListDirectory(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"b.csv", "A.CSV", "notes.txt", "results.csv.bak"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "exports.csv"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	dirs, files, err := listDirectory(dir, ".csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"exports.csv"}; !slices.Equal(dirs, want) {
+		t.Errorf("dirs = %v, want %v", dirs, want)
+	}
+	if want := []string{"A.CSV", "b.csv"}; !slices.Equal(files, want) {
+		t.Errorf("files = %v, want %v", files, want)
+	}
+
+	if _, files, _ := listDirectory(dir, ""); files != nil {
+		t.Errorf("listed files %v without an extension", files)
+	}
+}
@@ -0,0 +1,182 @@
+package manalyzer
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/akiver/cs-demo-analyzer/pkg/api"
+	"github.com/akiver/cs-demo-analyzer/pkg/api/constants"
+)
+
+// maxCachedResults bounds resultCache; it is emptied when full.
+const maxCachedResults = 16
+
+// maxParsedDemos bounds parsedDemos. Parsed matches are large, and with the
+// HTTP API every distinct demo posted would otherwise stay in memory; the
+// disk cache still spares evicted demos a full parse.
+const maxParsedDemos = 64
+
+// demoKey identifies a demo file on disk and the source it was parsed as; a
+// changed file gets a new key.
+type demoKey struct {
+	path    string
+	size    int64
+	modTime time.Time
+	source  constants.DemoSource
+}
+
+// parsedDemos caches the maxParsedDemos most recently used parsed matches
+// for the session, so Analyze on the same folder only parses new or changed
+// demos.
+var parsedDemos = newMatchCache(maxParsedDemos)
+
+// matchCache is a least recently used cache of parsed matches.
+type matchCache struct {
+	mu      sync.Mutex
+	limit   int
+	order   *list.List // Of *cachedMatch, most recently used first
+	entries map[demoKey]*list.Element
+}
+
+type cachedMatch struct {
+	key   demoKey
+	match *api.Match
+}
+
+func newMatchCache(limit int) *matchCache {
+	return &matchCache{limit: limit, order: list.New(), entries: make(map[demoKey]*list.Element)}
+}
+
+func (c *matchCache) get(key demoKey) (*api.Match, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*cachedMatch).match, true
+}
+
+// put adds match under key, evicting the least recently used match when the
+// cache is full.
+func (c *matchCache) put(key demoKey, match *api.Match) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		elem.Value.(*cachedMatch).match = match
+		c.order.MoveToFront(elem)
+		return
+	}
+	if c.order.Len() >= c.limit {
+		oldest := c.order.Remove(c.order.Back()).(*cachedMatch)
+		delete(c.entries, oldest.key)
+	}
+	c.entries[key] = c.order.PushFront(&cachedMatch{key: key, match: match})
+}
+
+// remove drops key from the cache, if present.
+func (c *matchCache) remove(key demoKey) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+	}
+}
+
+// gatherDemoCached is GatherDemoWithSource backed by parsedDemos and, when
+// enabled, the disk cache.
+func gatherDemoCached(path string, info os.FileInfo, source constants.DemoSource) (*api.Match, error) {
+	key := demoKey{path: path, size: info.Size(), modTime: info.ModTime(), source: source}
+
+	match, ok := parsedDemos.get(key)
+	if ok {
+		return match, nil
+	}
+
+	if match, ok = loadCachedDemo(key); !ok {
+		var err error
+		match, err = gatherDemoFile(path, source)
+		if err != nil {
+			return nil, err
+		}
+		storeCachedDemo(key, match)
+	}
+
+	parsedDemos.put(key, match)
+	return match, nil
+}
+
+// HashPreferences returns a stable hash of the preferences that change
+// aggregated results, used to key cached results. Logging, display and
+// cache settings are left out so changing them keeps the cache; a new
+// preference that affects ProcessMatches must be added here.
+func HashPreferences(p Preferences) string {
+	data, err := json.Marshal(struct {
+		AverageMode       string   `json:"averageMode"`
+		ExcludeSteamIDs   []string `json:"excludeSteamIds"`
+		CountFlashAssists bool     `json:"countFlashAssists"`
+		DemoSource        string   `json:"demoSource"`
+	}{p.AverageMode, p.ExcludeSteamIDs, p.CountFlashAssists, p.DemoSource})
+	if err != nil {
+		// Only plain fields are hashed, so this can't happen
+		panic(err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// resultCache holds aggregated results keyed by everything ProcessMatches
+// depends on, so Recompute with a previously used combination is instant.
+type resultCache struct {
+	mu      sync.Mutex
+	entries map[string]*WrangleResult
+}
+
+func newResultCache() *resultCache {
+	return &resultCache{entries: make(map[string]*WrangleResult)}
+}
+
+// resultKey combines the match set, tracked players, preferences and
+// analysis options into a cache key.
+func resultKey(matches []*api.Match, steamIDs []string, prefs Preferences, roundRange [2]int, minPlayers int) string {
+	ids := make([]string, 0, len(matches))
+	for _, match := range matches {
+		ids = append(ids, match.Checksum+"|"+match.DemoFilePath)
+	}
+	slices.Sort(ids)
+
+	players := slices.Clone(steamIDs)
+	slices.Sort(players)
+
+	h := sha256.New()
+	for _, id := range ids {
+		fmt.Fprintln(h, id)
+	}
+	fmt.Fprintln(h, players, roundRange, minPlayers, HashPreferences(prefs))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (c *resultCache) get(key string) (*WrangleResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	result, ok := c.entries[key]
+	return result, ok
+}
+
+func (c *resultCache) put(key string, result *WrangleResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= maxCachedResults {
+		clear(c.entries)
+	}
+	c.entries[key] = result
+}
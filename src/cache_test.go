@@ -0,0 +1,86 @@
+package manalyzer
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/akiver/cs-demo-analyzer/pkg/api"
+)
+
+func TestMatchCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := newMatchCache(2)
+	keys := []demoKey{{path: "a.dem"}, {path: "b.dem"}, {path: "c.dem"}}
+	matches := []*api.Match{{DemoFileName: "a.dem"}, {DemoFileName: "b.dem"}, {DemoFileName: "c.dem"}}
+
+	cache.put(keys[0], matches[0])
+	cache.put(keys[1], matches[1])
+	if _, ok := cache.get(keys[0]); !ok { // a is now the most recently used
+		t.Fatal("a.dem missing before the cache is full")
+	}
+	cache.put(keys[2], matches[2])
+
+	if _, ok := cache.get(keys[1]); ok {
+		t.Error("b.dem, the least recently used, was not evicted")
+	}
+	for _, i := range []int{0, 2} {
+		if match, ok := cache.get(keys[i]); !ok || match != matches[i] {
+			t.Errorf("%s = %v, %v; want its match", keys[i].path, match, ok)
+		}
+	}
+	if n := cache.order.Len(); n != 2 {
+		t.Errorf("cache holds %d matches, want 2", n)
+	}
+}
+
+func TestPreferenceChangeKeepsParsedDemos(t *testing.T) {
+	dir := t.TempDir()
+	match := newTestMatch("de_mirage", 24)
+	writeTestDemo(t, dir, match)
+	steamIDs := []string{strconv.FormatUint(alice, 10), strconv.FormatUint(bob, 10)}
+
+	results := newResultCache()
+	mean := testPreferences()
+	results.put(resultKey([]*api.Match{match}, steamIDs, mean, [2]int{}, 0), &WrangleResult{})
+
+	// The same input in another order still hits the result cache
+	reversed := []string{steamIDs[1], steamIDs[0]}
+	if _, ok := results.get(resultKey([]*api.Match{match}, reversed, mean, [2]int{}, 0)); !ok {
+		t.Error("same players in another order missed the result cache")
+	}
+
+	// Log and display settings don't change the result, so they still hit it
+	logging := testPreferences()
+	logging.LogLevel, logging.LogTarget, logging.LogMaxSizeMB = "debug", "stdout", 1
+	logging.TimeZone, logging.DemoCache = "Europe/Helsinki", !logging.DemoCache
+	if _, ok := results.get(resultKey([]*api.Match{match}, steamIDs, logging, [2]int{}, 0)); !ok {
+		t.Error("changed log and display settings missed the result cache")
+	}
+
+	// Every preference ProcessMatches reads misses it
+	for name, change := range map[string]func(*Preferences){
+		"ExcludeSteamIDs":   func(p *Preferences) { p.ExcludeSteamIDs = []string{steamIDs[1]} },
+		"CountFlashAssists": func(p *Preferences) { p.CountFlashAssists = !p.CountFlashAssists },
+		"DemoSource":        func(p *Preferences) { p.DemoSource = "faceit" },
+	} {
+		changed := testPreferences()
+		change(&changed)
+		if _, ok := results.get(resultKey([]*api.Match{match}, steamIDs, changed, [2]int{}, 0)); ok {
+			t.Errorf("changed %s hit the result cache", name)
+		}
+	}
+
+	// Another average mode misses it too, but the demo is not parsed again
+	median := testPreferences()
+	median.AverageMode = AverageModeMedian
+	if _, ok := results.get(resultKey([]*api.Match{match}, steamIDs, median, [2]int{}, 0)); ok {
+		t.Error("changed preferences hit the result cache")
+	}
+	matches, _, err := GatherLatestDemosWithReport(context.Background(), dir, 0, median.ParserSource())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 || matches[0] != match {
+		t.Error("the demo was parsed again instead of coming from the parse cache")
+	}
+}
@@ -0,0 +1,107 @@
+package manalyzer
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// Text sizes in PNG charts, in screen pixels per chartFont pixel.
+const (
+	chartTextScale  = 2
+	chartTitleScale = 3
+)
+
+// image draws the chart with the layout of svg, using chartFont for text.
+func (c barChart) image() *image.RGBA {
+	maxValue := c.maxValue()
+	barArea := float64(chartWidth - chartLabelWidth - chartValueWidth)
+	groupHeight := len(c.series)*chartBarHeight + chartGroupGap
+	height := chartHeaderSpace + len(c.groups)*groupHeight + chartGroupGap
+
+	img := image.NewRGBA(image.Rect(0, 0, chartWidth, height))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	drawText(img, 10, 4, c.title, chartTitleScale)
+
+	x := 10
+	for i, series := range c.series {
+		fillRect(img, image.Rect(x, 38, x+12, 50), chartColor(i))
+		drawText(img, x+16, 37, series, chartTextScale)
+		x += 16 + textWidth(series, chartTextScale) + 20
+	}
+
+	// Capitals are 7 font pixels high, so text is centered by placing its
+	// top 3.5 of them above a middle line
+	textOffset := 7 * chartTextScale / 2
+	y := chartHeaderSpace
+	for _, group := range c.groups {
+		label := fitText(group.label, chartLabelWidth-16, chartTextScale)
+		labelY := y + len(c.series)*chartBarHeight/2 - textOffset
+		drawText(img, chartLabelWidth-8-textWidth(label, chartTextScale), labelY, label, chartTextScale)
+		for i, value := range group.values {
+			if !group.ok[i] {
+				continue
+			}
+			barY := y + i*chartBarHeight
+			width := 0
+			if maxValue > 0 {
+				width = int(value / maxValue * barArea)
+			}
+			fillRect(img, image.Rect(chartLabelWidth, barY+1, chartLabelWidth+width, barY+chartBarHeight-1), chartColor(i))
+			drawText(img, chartLabelWidth+width+4, barY+chartBarHeight/2-textOffset, fmt.Sprintf("%.1f", value), chartTextScale)
+		}
+		y += groupHeight
+	}
+	return img
+}
+
+// chartColor returns the color of series i.
+func chartColor(i int) color.RGBA {
+	c := color.RGBA{A: 0xff}
+	fmt.Sscanf(chartColors[i%len(chartColors)], "#%02x%02x%02x", &c.R, &c.G, &c.B)
+	return c
+}
+
+func fillRect(img *image.RGBA, r image.Rectangle, c color.Color) {
+	draw.Draw(img, r, image.NewUniform(c), image.Point{}, draw.Src)
+}
+
+// textWidth returns the width in pixels of s drawn by drawText.
+func textWidth(s string, scale int) int {
+	n := len([]rune(s))
+	if n == 0 {
+		return 0
+	}
+	// Glyphs are 5 pixels wide with a 1 pixel gap between them
+	return (6*n - 1) * scale
+}
+
+// fitText shortens s until it is at most width pixels wide.
+func fitText(s string, width, scale int) string {
+	runes := []rune(s)
+	for len(runes) > 0 && textWidth(string(runes), scale) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes)
+}
+
+// drawText draws s in black with its top left corner at x, y, each font
+// pixel as a scale by scale square.
+func drawText(img *image.RGBA, x, y int, s string, scale int) {
+	for _, r := range s {
+		glyph, ok := chartFont[r]
+		if !ok {
+			glyph = chartFont['?']
+		}
+		for row, bits := range glyph {
+			for col := 0; col < 5; col++ {
+				if bits&(0x10>>col) != 0 {
+					px, py := x+col*scale, y+row*scale
+					fillRect(img, image.Rect(px, py, px+scale, py+scale), color.Black)
+				}
+			}
+		}
+		x += 6 * scale
+	}
+}
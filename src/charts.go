@@ -0,0 +1,366 @@
+package manalyzer
+
+import (
+	"fmt"
+	"html"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Chart types for RenderChartSVG, also the exported file names.
+const (
+	ChartPlayerComparison = "player-comparison"
+	ChartSidePerformance  = "side-performance"
+	ChartMapBreakdown     = "map-breakdown"
+	ChartMapSideWinRate   = "map-side-winrate"
+	ChartWeaponKills      = "weapon-kills"
+)
+
+// ChartTypes lists every chart ExportCharts writes.
+var ChartTypes = []string{ChartPlayerComparison, ChartSidePerformance, ChartMapBreakdown, ChartMapSideWinRate, ChartWeaponKills}
+
+// Chart layout in SVG pixels.
+const (
+	chartWidth       = 800
+	chartLabelWidth  = 170 // Group labels left of the bars
+	chartValueWidth  = 72  // Value text right of the longest bar
+	chartBarHeight   = 16
+	chartGroupGap    = 14
+	chartHeaderSpace = 64 // Title and legend
+
+	// chartWeapons is how many weapons the weapon chart shows, those with
+	// the most kills.
+	chartWeapons = 10
+)
+
+// chartColors are the series colors, in order.
+var chartColors = []string{"#4e79a7", "#f28e2b", "#59a14f", "#e15759", "#76b7b2", "#b07aa1", "#edc948"}
+
+// barChart is a horizontal grouped bar chart: one group of bars per label,
+// one bar per series.
+type barChart struct {
+	title  string
+	series []string
+	groups []barGroup
+}
+
+type barGroup struct {
+	label  string
+	values []float64 // By series
+	ok     []bool    // False for series without data, which get no bar
+}
+
+// RenderChartSVG draws one of the ChartTypes for result as a static SVG
+// image, for sharing where the terminal UI isn't available.
+func RenderChartSVG(result *WrangleResult, chartType string, w io.Writer) error {
+	chart, err := buildChart(result, chartType)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, chart.svg())
+	return err
+}
+
+// RenderChartPNG is RenderChartSVG as a PNG image, which chat apps such as
+// Discord preview inline.
+func RenderChartPNG(result *WrangleResult, chartType string, w io.Writer) error {
+	chart, err := buildChart(result, chartType)
+	if err != nil {
+		return err
+	}
+	return png.Encode(w, chart.image())
+}
+
+// buildChart collects the data of chartType from result.
+func buildChart(result *WrangleResult, chartType string) (barChart, error) {
+	if result == nil {
+		return barChart{}, fmt.Errorf("no results to chart")
+	}
+
+	players := slices.DeleteFunc(slices.Clone(result.PlayerStats), func(p *PlayerStats) bool { return p == nil })
+	sort.SliceStable(players, func(i, j int) bool {
+		return players[i].PlayerName < players[j].PlayerName
+	})
+
+	var chart barChart
+	switch chartType {
+	case ChartPlayerComparison:
+		chart = playerComparisonChart(players)
+	case ChartSidePerformance:
+		chart = sidePerformanceChart(players)
+	case ChartMapBreakdown:
+		chart = mapBreakdownChart(players)
+	case ChartMapSideWinRate:
+		chart = mapSideWinRateChart(players)
+	case ChartWeaponKills:
+		chart = weaponKillsChart(players)
+	default:
+		return barChart{}, fmt.Errorf("unknown chart %q (want one of %s)", chartType, strings.Join(ChartTypes, ", "))
+	}
+	return chart, nil
+}
+
+// ExportCharts writes every chart in ChartTypes to dir as <type>.png and
+// <type>.svg, then each player's trend chart as trend-<SteamID64>.png and
+// .svg, and returns the paths written.
+func ExportCharts(result *WrangleResult, dir string) ([]string, error) {
+	if result == nil {
+		return nil, fmt.Errorf("no results to chart")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("cannot create chart directory: %w", err)
+	}
+
+	// Each chart is rendered by the PNG and then the SVG function
+	type chartRenderer func(*WrangleResult, string, io.Writer) error
+	type chartFile struct {
+		name    string
+		chart   string // Chart type, or the SteamID64 of a trend chart
+		formats [2]chartRenderer
+	}
+	var files []chartFile
+	for _, chartType := range ChartTypes {
+		files = append(files, chartFile{chartType, chartType, [2]chartRenderer{RenderChartPNG, RenderChartSVG}})
+	}
+	for _, playerStats := range result.PlayerStats {
+		if playerStats != nil {
+			files = append(files, chartFile{ChartTrend + "-" + playerStats.SteamID64, playerStats.SteamID64,
+				[2]chartRenderer{RenderTrendPNG, RenderTrendSVG}})
+		}
+	}
+
+	var paths []string
+	for _, file := range files {
+		for i, ext := range []string{".png", ".svg"} {
+			path := filepath.Join(dir, file.name+ext)
+			f, err := os.Create(path)
+			if err != nil {
+				return paths, fmt.Errorf("cannot create chart: %w", err)
+			}
+			err = file.formats[i](result, file.chart, f)
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				return paths, fmt.Errorf("cannot write %s: %w", path, err)
+			}
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
+
+// playerComparisonChart compares the players' overall KAST and ADR.
+func playerComparisonChart(players []*PlayerStats) barChart {
+	chart := barChart{title: "Player comparison", series: []string{"KAST%", "ADR"}}
+	for _, playerStats := range players {
+		if overall := playerStats.OverallStats; overall != nil {
+			chart.groups = append(chart.groups, barGroup{
+				label:  playerStats.ShownName(),
+				values: []float64{overall.KAST, overall.ADR},
+				ok:     []bool{true, true},
+			})
+		}
+	}
+	return chart
+}
+
+// sidePerformanceChart compares each player's KAST and ADR on T and CT,
+// over every map.
+func sidePerformanceChart(players []*PlayerStats) barChart {
+	chart := barChart{title: "Side performance", series: []string{"T KAST%", "CT KAST%", "T ADR", "CT ADR"}}
+	for _, playerStats := range players {
+		group := barGroup{label: playerStats.ShownName(), values: make([]float64, 4), ok: make([]bool, 4)}
+		for i, side := range []string{"T", "CT"} {
+			var rounds, damageRounds int
+			var kastRounds, damage float64
+			for _, mapStats := range playerStats.MapStats {
+				sideStats := mapStats.SideStats[side]
+				if sideStats == nil {
+					continue
+				}
+				rounds += sideStats.RoundsPlayed
+				kastRounds += sideStats.KAST / 100 * float64(sideStats.RoundsPlayed)
+				// As in calculateOverallStats, rounds without damage data
+				// would read as 0 ADR
+				damageRounds += sideStats.DamageRounds
+				damage += sideStats.ADR * float64(sideStats.DamageRounds)
+			}
+			if rounds > 0 {
+				group.values[i], group.ok[i] = kastRounds/float64(rounds)*100, true
+			}
+			if damageRounds > 0 {
+				group.values[i+2], group.ok[i+2] = damage/float64(damageRounds), true
+			}
+		}
+		chart.groups = append(chart.groups, group)
+	}
+	return chart
+}
+
+// mapBreakdownChart shows each player's ADR per map, both sides combined.
+func mapBreakdownChart(players []*PlayerStats) barChart {
+	chart := barChart{title: "ADR by map"}
+	var maps []string
+	for _, playerStats := range players {
+		chart.series = append(chart.series, playerStats.ShownName())
+		for mapName := range playerStats.MapStats {
+			if !slices.Contains(maps, mapName) {
+				maps = append(maps, mapName)
+			}
+		}
+	}
+	slices.Sort(maps)
+
+	for _, mapName := range maps {
+		group := barGroup{label: mapName, values: make([]float64, len(players)), ok: make([]bool, len(players))}
+		for i, playerStats := range players {
+			mapStats := playerStats.MapStats[mapName]
+			if mapStats == nil {
+				continue
+			}
+			var damageRounds int
+			var damage float64
+			for _, sideStats := range mapStats.SideStats {
+				damageRounds += sideStats.DamageRounds
+				damage += sideStats.ADR * float64(sideStats.DamageRounds)
+			}
+			if damageRounds > 0 {
+				group.values[i], group.ok[i] = damage/float64(damageRounds), true
+			}
+		}
+		chart.groups = append(chart.groups, group)
+	}
+	return chart
+}
+
+// mapSideWinRateChart shows the round win rate on T and CT per map, over
+// every player's rounds. A side nobody played on a map gets no bar.
+func mapSideWinRateChart(players []*PlayerStats) barChart {
+	chart := barChart{title: "Round win rate by map and side", series: []string{"T win%", "CT win%"}}
+	var maps []string
+	for _, playerStats := range players {
+		for mapName := range playerStats.MapStats {
+			if !slices.Contains(maps, mapName) {
+				maps = append(maps, mapName)
+			}
+		}
+	}
+	slices.Sort(maps)
+
+	for _, mapName := range maps {
+		group := barGroup{label: mapName, values: make([]float64, 2), ok: make([]bool, 2)}
+		for i, side := range []string{"T", "CT"} {
+			var won, played int
+			for _, playerStats := range players {
+				if mapStats := playerStats.MapStats[mapName]; mapStats != nil && mapStats.SideStats[side] != nil {
+					won += mapStats.SideStats[side].RoundsWon
+					played += mapStats.SideStats[side].RoundsPlayed
+				}
+			}
+			if played > 0 {
+				group.values[i], group.ok[i] = percentage(won, played), true
+			}
+		}
+		chart.groups = append(chart.groups, group)
+	}
+	return chart
+}
+
+// weaponKillsChart shows each player's kills with the chartWeapons weapons
+// that got the most kills over all players.
+func weaponKillsChart(players []*PlayerStats) barChart {
+	chart := barChart{title: "Kills by weapon"}
+	totals := make(map[string]int)
+	for _, playerStats := range players {
+		chart.series = append(chart.series, playerStats.ShownName())
+		for weapon, kills := range playerStats.WeaponKills {
+			totals[weapon] += kills
+		}
+	}
+	weapons := make([]string, 0, len(totals))
+	for weapon := range totals {
+		weapons = append(weapons, weapon)
+	}
+	sort.Slice(weapons, func(i, j int) bool {
+		if totals[weapons[i]] != totals[weapons[j]] {
+			return totals[weapons[i]] > totals[weapons[j]]
+		}
+		return weapons[i] < weapons[j]
+	})
+	if len(weapons) > chartWeapons {
+		weapons = weapons[:chartWeapons]
+	}
+
+	for _, weapon := range weapons {
+		group := barGroup{label: weapon, values: make([]float64, len(players)), ok: make([]bool, len(players))}
+		for i, playerStats := range players {
+			if kills := playerStats.WeaponKills[weapon]; kills > 0 {
+				group.values[i], group.ok[i] = float64(kills), true
+			}
+		}
+		chart.groups = append(chart.groups, group)
+	}
+	return chart
+}
+
+// svg draws the chart with every bar on one scale, from 0 to the largest
+// value, and the value printed after each bar.
+func (c barChart) svg() string {
+	maxValue := c.maxValue()
+	barArea := float64(chartWidth - chartLabelWidth - chartValueWidth)
+	groupHeight := len(c.series)*chartBarHeight + chartGroupGap
+	height := chartHeaderSpace + len(c.groups)*groupHeight + chartGroupGap
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="sans-serif" font-size="12">`+"\n", chartWidth, height)
+	fmt.Fprintf(&b, `<rect width="100%%" height="100%%" fill="#ffffff"/>`+"\n")
+	fmt.Fprintf(&b, `<text x="10" y="24" font-size="18" font-weight="bold">%s</text>`+"\n", html.EscapeString(c.title))
+
+	x := 10
+	for i, series := range c.series {
+		fmt.Fprintf(&b, `<rect x="%d" y="38" width="12" height="12" fill="%s"/>`+"\n", x, chartColors[i%len(chartColors)])
+		fmt.Fprintf(&b, `<text x="%d" y="48">%s</text>`+"\n", x+16, html.EscapeString(series))
+		x += 16 + 7*len([]rune(series)) + 20
+	}
+
+	y := chartHeaderSpace
+	for _, group := range c.groups {
+		labelY := y + len(c.series)*chartBarHeight/2 + 4
+		fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="end">%s</text>`+"\n", chartLabelWidth-8, labelY, html.EscapeString(group.label))
+		for i, value := range group.values {
+			barY := y + i*chartBarHeight
+			if !group.ok[i] {
+				continue
+			}
+			width := 0.0
+			if maxValue > 0 {
+				width = value / maxValue * barArea
+			}
+			fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%.1f" height="%d" fill="%s"/>`+"\n",
+				chartLabelWidth, barY+1, width, chartBarHeight-2, chartColors[i%len(chartColors)])
+			fmt.Fprintf(&b, `<text x="%.1f" y="%d">%.1f</text>`+"\n", float64(chartLabelWidth)+width+4, barY+chartBarHeight-4, value)
+		}
+		y += groupHeight
+	}
+	b.WriteString("</svg>\n")
+	return b.String()
+}
+
+// maxValue returns the largest value charted, or 0 if there is none.
+func (c barChart) maxValue() float64 {
+	maxValue := 0.0
+	for _, group := range c.groups {
+		for i, value := range group.values {
+			if group.ok[i] {
+				maxValue = max(maxValue, value)
+			}
+		}
+	}
+	return maxValue
+}
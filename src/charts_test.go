@@ -0,0 +1,236 @@
+package manalyzer
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"image/png"
+	"io"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/akiver/cs-demo-analyzer/pkg/api"
+	"github.com/akiver/cs-demo-analyzer/pkg/api/constants"
+	"github.com/markus-wa/demoinfocs-golang/v4/pkg/demoinfocs/common"
+)
+
+// chartTestResult has alice and bob on three maps, alice dealing more damage
+// on each map than the last and getting some AK-47 kills. T wins the even
+// rounds.
+func chartTestResult(t *testing.T) *WrangleResult {
+	t.Helper()
+	var matches []*api.Match
+	for i, mapName := range []string{"de_mirage", "de_inferno", "de_nuke"} {
+		match := newTestMatch(mapName, 24)
+		for n := 1; n <= 24; n++ {
+			if n%2 == 0 {
+				match.Rounds[n-1].WinnerSide = common.TeamTerrorists
+			}
+			addDamage(match, n, 300, alice, testOpponent(match, alice, n), 40+20*i)
+			addDamage(match, n, 300, bob, testOpponent(match, bob, n), 70)
+			if n%4 == 0 {
+				addKill(match, n, 400, alice, testOpponent(match, alice, n)).WeaponName = constants.WeaponAK47
+			}
+		}
+		matches = append(matches, match)
+	}
+	return processTestMatches(t, matches, alice, bob)
+}
+
+func TestRenderChart(t *testing.T) {
+	result := chartTestResult(t)
+	for _, chartType := range ChartTypes {
+		t.Run(chartType, func(t *testing.T) {
+			var svg bytes.Buffer
+			if err := RenderChartSVG(result, chartType, &svg); err != nil {
+				t.Fatal(err)
+			}
+			decoder := xml.NewDecoder(&svg)
+			for {
+				if _, err := decoder.Token(); err == io.EOF {
+					break
+				} else if err != nil {
+					t.Fatalf("invalid SVG: %v", err)
+				}
+			}
+
+			var buf bytes.Buffer
+			if err := RenderChartPNG(result, chartType, &buf); err != nil {
+				t.Fatal(err)
+			}
+			img, err := png.Decode(&buf)
+			if err != nil {
+				t.Fatalf("invalid PNG: %v", err)
+			}
+			if width := img.Bounds().Dx(); width != chartWidth {
+				t.Errorf("PNG is %d pixels wide, want %d", width, chartWidth)
+			}
+			// The first bar starts at the left of the bar area, in the
+			// first series' color
+			r, g, b, _ := img.At(chartLabelWidth+1, chartHeaderSpace+chartBarHeight/2).RGBA()
+			want := chartColor(0)
+			if uint8(r>>8) != want.R || uint8(g>>8) != want.G || uint8(b>>8) != want.B {
+				t.Errorf("first bar pixel is %02x%02x%02x, want %v", r>>8, g>>8, b>>8, chartColors[0])
+			}
+		})
+	}
+
+	if err := RenderChartPNG(result, "heatmap", io.Discard); err == nil {
+		t.Error("unknown chart type rendered without error")
+	}
+}
+
+func TestMapBreakdownChart(t *testing.T) {
+	chart, err := buildChart(chartTestResult(t), ChartMapBreakdown)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"alice", "bob"}; !slices.Equal(chart.series, want) {
+		t.Errorf("series = %v, want %v", chart.series, want)
+	}
+	want := map[string][]float64{"de_inferno": {60, 70}, "de_mirage": {40, 70}, "de_nuke": {80, 70}}
+	if len(chart.groups) != len(want) {
+		t.Fatalf("got %d maps, want %d", len(chart.groups), len(want))
+	}
+	for _, group := range chart.groups {
+		if !slices.Equal(group.values, want[group.label]) {
+			t.Errorf("%s ADR = %v, want %v", group.label, group.values, want[group.label])
+		}
+	}
+}
+
+func TestMapSideWinRateChart(t *testing.T) {
+	// CT wins every round; alice plays only CT on Nuke.
+	matches := []*api.Match{newTestMatch("de_mirage", 24), newTestMatch("de_nuke", mr12HalfLength)}
+	chart, err := buildChart(processTestMatches(t, matches, alice), ChartMapSideWinRate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"T win%", "CT win%"}; !slices.Equal(chart.series, want) {
+		t.Errorf("series = %v, want %v", chart.series, want)
+	}
+	want := []barGroup{
+		{label: "de_mirage", values: []float64{0, 100}, ok: []bool{true, true}},
+		{label: "de_nuke", values: []float64{0, 100}, ok: []bool{false, true}},
+	}
+	if len(chart.groups) != len(want) {
+		t.Fatalf("got %d maps, want %d", len(chart.groups), len(want))
+	}
+	for i, group := range chart.groups {
+		if group.label != want[i].label || !slices.Equal(group.values, want[i].values) || !slices.Equal(group.ok, want[i].ok) {
+			t.Errorf("group %d = %+v, want %+v", i, group, want[i])
+		}
+	}
+}
+
+func TestWeaponKillsChart(t *testing.T) {
+	match := newTestMatch("de_mirage", 6)
+	kills := []struct {
+		killer uint64
+		weapon constants.WeaponName
+	}{
+		{alice, constants.WeaponAK47}, {alice, constants.WeaponAK47}, {alice, constants.WeaponAK47},
+		{alice, constants.WeaponAWP}, {bob, constants.WeaponAWP}, {bob, constants.WeaponAWP},
+	}
+	for i, k := range kills {
+		kill := addKill(match, i+1, 300, k.killer, testOpponent(match, k.killer, i+1))
+		kill.WeaponName = k.weapon
+	}
+	chart, err := buildChart(processTestMatches(t, []*api.Match{match}, alice, bob), ChartWeaponKills)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"alice", "bob"}; !slices.Equal(chart.series, want) {
+		t.Errorf("series = %v, want %v", chart.series, want)
+	}
+	// Tied on 3 kills, so in name order
+	want := []barGroup{
+		{label: "AK-47", values: []float64{3, 0}, ok: []bool{true, false}},
+		{label: "AWP", values: []float64{1, 2}, ok: []bool{true, true}},
+	}
+	if len(chart.groups) != len(want) {
+		t.Fatalf("got %d weapons, want %d", len(chart.groups), len(want))
+	}
+	for i, group := range chart.groups {
+		if group.label != want[i].label || !slices.Equal(group.values, want[i].values) || !slices.Equal(group.ok, want[i].ok) {
+			t.Errorf("group %d = %+v, want %+v", i, group, want[i])
+		}
+	}
+}
+
+func TestTrendChart(t *testing.T) {
+	result := chartTestResult(t)
+	steamID64 := strconv.FormatUint(alice, 10)
+	chart, err := buildTrendChart(result, steamID64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"KAST%", "ADR"}; !slices.Equal(chart.series, want) {
+		t.Errorf("series = %v, want %v", chart.series, want)
+	}
+	// chartTestResult's matches are played in map order
+	if want := []float64{40, 60, 80}; !slices.Equal(chart.values[1], want) {
+		t.Errorf("ADR by match = %v, want %v", chart.values[1], want)
+	}
+	if len(chart.labels) != 3 || !slices.IsSorted(chart.labels) {
+		t.Errorf("labels = %v, want 3 dates in order", chart.labels)
+	}
+
+	var svg, img bytes.Buffer
+	if err := RenderTrendSVG(result, steamID64, &svg); err != nil {
+		t.Fatal(err)
+	}
+	if err := xml.Unmarshal(svg.Bytes(), new(struct{})); err != nil {
+		t.Errorf("invalid SVG: %v", err)
+	}
+	if err := RenderTrendPNG(result, steamID64, &img); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := png.Decode(&img); err != nil {
+		t.Errorf("invalid PNG: %v", err)
+	}
+
+	if _, err := buildTrendChart(result, strconv.FormatUint(carol, 10)); err == nil {
+		t.Error("charted the trend of a player without results")
+	}
+}
+
+func TestExportCharts(t *testing.T) {
+	dir := t.TempDir()
+	paths, err := ExportCharts(chartTestResult(t), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want []string
+	for _, chartType := range ChartTypes {
+		want = append(want, filepath.Join(dir, chartType+".png"), filepath.Join(dir, chartType+".svg"))
+	}
+	for _, steamID64 := range []uint64{alice, bob} {
+		name := fmt.Sprintf("%s-%d", ChartTrend, steamID64)
+		want = append(want, filepath.Join(dir, name+".png"), filepath.Join(dir, name+".svg"))
+	}
+	// Trend charts follow the players' order in the result
+	slices.Sort(paths[len(ChartTypes)*2:])
+	if !slices.Equal(paths, want) {
+		t.Errorf("wrote %v, want %v", paths, want)
+	}
+}
+
+func TestAliasesInChartSeries(t *testing.T) {
+	result := chartTestResult(t)
+	aliased := result.WithAliases(map[string]string{strconv.FormatUint(alice, 10): "A"})
+
+	chart, err := buildChart(aliased, ChartMapBreakdown)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"A", "bob"}; !slices.Equal(chart.series, want) {
+		t.Errorf("series = %v, want %v", chart.series, want)
+	}
+	if name := testPlayerStats(t, result, alice).ShownName(); name != "alice" {
+		t.Errorf("the aliased copy renamed the original to %q", name)
+	}
+}
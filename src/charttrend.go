@@ -0,0 +1,240 @@
+package manalyzer
+
+import (
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+	"sort"
+	"strings"
+)
+
+// ChartTrend is the trend chart's file name prefix; ExportCharts writes one
+// per player as trend-<SteamID64>.
+const ChartTrend = "trend"
+
+// Trend chart layout in pixels, around the plot area.
+const (
+	trendHeight      = 360
+	trendAxisWidth   = 80 // Value labels left of the plot
+	trendRightMargin = 30
+	trendDateSpace   = 40 // Date labels below the plot
+	trendPointSize   = 6
+)
+
+// trendDateFormat labels the first and last match on the trend chart.
+const trendDateFormat = "2006-01-02"
+
+// lineChart is a line chart with one line per series over the same points,
+// e.g. matches in date order.
+type lineChart struct {
+	title  string
+	series []string
+	labels []string    // By point
+	values [][]float64 // By series, then point
+}
+
+// RenderTrendSVG draws the KAST and ADR of each of the player's matches in
+// result, oldest first, as a static SVG line chart.
+func RenderTrendSVG(result *WrangleResult, steamID64 string, w io.Writer) error {
+	chart, err := buildTrendChart(result, steamID64)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, chart.svg())
+	return err
+}
+
+// RenderTrendPNG is RenderTrendSVG as a PNG image.
+func RenderTrendPNG(result *WrangleResult, steamID64 string, w io.Writer) error {
+	chart, err := buildTrendChart(result, steamID64)
+	if err != nil {
+		return err
+	}
+	return png.Encode(w, chart.image())
+}
+
+// buildTrendChart collects the KAST and ADR per match of the player with
+// steamID64, in date order.
+func buildTrendChart(result *WrangleResult, steamID64 string) (lineChart, error) {
+	if result == nil {
+		return lineChart{}, fmt.Errorf("no results to chart")
+	}
+	var playerStats *PlayerStats
+	for _, p := range result.PlayerStats {
+		if p != nil && p.SteamID64 == steamID64 {
+			playerStats = p
+		}
+	}
+	if playerStats == nil {
+		return lineChart{}, fmt.Errorf("no results for player %s", steamID64)
+	}
+
+	var matches []*MatchStatistics
+	for _, mapStats := range playerStats.MapStats {
+		matches = append(matches, mapStats.Matches...)
+	}
+	if len(matches) == 0 {
+		return lineChart{}, fmt.Errorf("no matches for player %s", steamID64)
+	}
+	sortMatchesByDate(matches)
+
+	chart := lineChart{
+		title:  playerStats.ShownName() + ": KAST and ADR per match",
+		series: []string{"KAST%", "ADR"},
+		values: make([][]float64, 2),
+	}
+	for _, matchStats := range matches {
+		overall := matchStats.Overall()
+		chart.labels = append(chart.labels, matchStats.Date.Format(trendDateFormat))
+		chart.values[0] = append(chart.values[0], overall.KAST)
+		chart.values[1] = append(chart.values[1], overall.ADR)
+	}
+	return chart, nil
+}
+
+// sortMatchesByDate orders matches oldest first, by demo file name on the
+// same date.
+func sortMatchesByDate(matches []*MatchStatistics) {
+	sort.SliceStable(matches, func(i, j int) bool {
+		if !matches[i].Date.Equal(matches[j].Date) {
+			return matches[i].Date.Before(matches[j].Date)
+		}
+		return matches[i].DemoFileName < matches[j].DemoFileName
+	})
+}
+
+// maxValue returns the largest value charted, or 0 if there is none.
+func (c lineChart) maxValue() float64 {
+	maxValue := 0.0
+	for _, values := range c.values {
+		for _, value := range values {
+			maxValue = max(maxValue, value)
+		}
+	}
+	return maxValue
+}
+
+// point returns the position of point i with value, on a scale from 0 at
+// the bottom of the plot to maxValue at the top.
+func (c lineChart) point(i int, value, maxValue float64) (x, y float64) {
+	left, right := float64(trendAxisWidth), float64(chartWidth-trendRightMargin)
+	top, bottom := float64(chartHeaderSpace), float64(trendHeight-trendDateSpace)
+	x = (left + right) / 2
+	if len(c.labels) > 1 {
+		x = left + float64(i)*(right-left)/float64(len(c.labels)-1)
+	}
+	y = bottom
+	if maxValue > 0 {
+		y = bottom - value/maxValue*(bottom-top)
+	}
+	return x, y
+}
+
+// svg draws the chart with a line and a dot per point for each series, the
+// value scale on the left and the first and last labels below.
+func (c lineChart) svg() string {
+	maxValue := c.maxValue()
+	bottom := trendHeight - trendDateSpace
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="sans-serif" font-size="12">`+"\n", chartWidth, trendHeight)
+	fmt.Fprintf(&b, `<rect width="100%%" height="100%%" fill="#ffffff"/>`+"\n")
+	fmt.Fprintf(&b, `<text x="10" y="24" font-size="18" font-weight="bold">%s</text>`+"\n", html.EscapeString(c.title))
+
+	x := 10
+	for i, series := range c.series {
+		fmt.Fprintf(&b, `<rect x="%d" y="38" width="12" height="12" fill="%s"/>`+"\n", x, chartColors[i%len(chartColors)])
+		fmt.Fprintf(&b, `<text x="%d" y="48">%s</text>`+"\n", x+16, html.EscapeString(series))
+		x += 16 + 7*len([]rune(series)) + 20
+	}
+
+	fmt.Fprintf(&b, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="#999999"/>`+"\n", trendAxisWidth, chartHeaderSpace, trendAxisWidth, bottom)
+	fmt.Fprintf(&b, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="#999999"/>`+"\n", trendAxisWidth, bottom, chartWidth-trendRightMargin, bottom)
+	fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="end">%.1f</text>`+"\n", trendAxisWidth-6, chartHeaderSpace+4, maxValue)
+	fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="end">0</text>`+"\n", trendAxisWidth-6, bottom+4)
+	if len(c.labels) > 0 {
+		fmt.Fprintf(&b, `<text x="%d" y="%d">%s</text>`+"\n", trendAxisWidth, bottom+20, html.EscapeString(c.labels[0]))
+		if len(c.labels) > 1 {
+			fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="end">%s</text>`+"\n", chartWidth-trendRightMargin, bottom+20, html.EscapeString(c.labels[len(c.labels)-1]))
+		}
+	}
+
+	for i, values := range c.values {
+		lineColor := chartColors[i%len(chartColors)]
+		points := make([]string, len(values))
+		for j, value := range values {
+			px, py := c.point(j, value, maxValue)
+			points[j] = fmt.Sprintf("%.1f,%.1f", px, py)
+			fmt.Fprintf(&b, `<circle cx="%.1f" cy="%.1f" r="%d" fill="%s"/>`+"\n", px, py, trendPointSize/2, lineColor)
+		}
+		fmt.Fprintf(&b, `<polyline points="%s" fill="none" stroke="%s" stroke-width="2"/>`+"\n", strings.Join(points, " "), lineColor)
+	}
+	b.WriteString("</svg>\n")
+	return b.String()
+}
+
+// image draws the chart with the layout of svg, using chartFont for text.
+func (c lineChart) image() *image.RGBA {
+	maxValue := c.maxValue()
+	bottom := trendHeight - trendDateSpace
+	axisColor := color.RGBA{0x99, 0x99, 0x99, 0xff}
+
+	img := image.NewRGBA(image.Rect(0, 0, chartWidth, trendHeight))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	drawText(img, 10, 4, c.title, chartTitleScale)
+
+	x := 10
+	for i, series := range c.series {
+		fillRect(img, image.Rect(x, 38, x+12, 50), chartColor(i))
+		drawText(img, x+16, 37, series, chartTextScale)
+		x += 16 + textWidth(series, chartTextScale) + 20
+	}
+
+	// As in barChart.image, text is centered on a line by starting 3.5
+	// font pixels above it
+	textOffset := 7 * chartTextScale / 2
+	fillRect(img, image.Rect(trendAxisWidth, chartHeaderSpace, trendAxisWidth+1, bottom+1), axisColor)
+	fillRect(img, image.Rect(trendAxisWidth, bottom, chartWidth-trendRightMargin, bottom+1), axisColor)
+	top := fmt.Sprintf("%.1f", maxValue)
+	drawText(img, trendAxisWidth-6-textWidth(top, chartTextScale), chartHeaderSpace-textOffset, top, chartTextScale)
+	drawText(img, trendAxisWidth-6-textWidth("0", chartTextScale), bottom-textOffset, "0", chartTextScale)
+	if len(c.labels) > 0 {
+		drawText(img, trendAxisWidth, bottom+8, c.labels[0], chartTextScale)
+		if len(c.labels) > 1 {
+			last := c.labels[len(c.labels)-1]
+			drawText(img, chartWidth-trendRightMargin-textWidth(last, chartTextScale), bottom+8, last, chartTextScale)
+		}
+	}
+
+	for i, values := range c.values {
+		lineColor := chartColor(i)
+		for j, value := range values {
+			px, py := c.point(j, value, maxValue)
+			if j > 0 {
+				fromX, fromY := c.point(j-1, values[j-1], maxValue)
+				drawLine(img, fromX, fromY, px, py, lineColor)
+			}
+			half := trendPointSize / 2
+			fillRect(img, image.Rect(int(px)-half, int(py)-half, int(px)+half, int(py)+half), lineColor)
+		}
+	}
+	return img
+}
+
+// drawLine draws a two pixel wide line from x0, y0 to x1, y1.
+func drawLine(img *image.RGBA, x0, y0, x1, y1 float64, c color.Color) {
+	steps := int(max(math.Abs(x1-x0), math.Abs(y1-y0)))
+	for s := 0; s <= steps; s++ {
+		t := 0.0
+		if steps > 0 {
+			t = float64(s) / float64(steps)
+		}
+		x, y := int(x0+t*(x1-x0)), int(y0+t*(y1-y0))
+		fillRect(img, image.Rect(x-1, y-1, x+1, y+1), c)
+	}
+}
@@ -0,0 +1,142 @@
+package manalyzer
+
+import (
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/akiver/cs-demo-analyzer/pkg/api"
+	"github.com/akiver/cs-demo-analyzer/pkg/api/constants"
+)
+
+// Compressed demo extensions, as Valve and FACEIT hand out downloads.
+const (
+	demoExtBzip2 = ".dem.bz2"
+	demoExtGzip  = ".dem.gz"
+)
+
+// maxDemoSize caps a decompressed demo, so a corrupt or malicious archive
+// can't fill the temporary directory. Real demos stay well below it.
+const maxDemoSize = 4 << 30
+
+// isDemoFile reports whether listDemos should pick up path: a .dem file or a
+// compressed one.
+func isDemoFile(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".dem") || compressedDemoExt(path) != ""
+}
+
+// compressedDemoExt returns the compressed demo extension of path, or "" for
+// anything else.
+func compressedDemoExt(path string) string {
+	lower := strings.ToLower(path)
+	for _, ext := range []string{demoExtBzip2, demoExtGzip} {
+		if strings.HasSuffix(lower, ext) {
+			return ext
+		}
+	}
+	return ""
+}
+
+// gatherDemoFile is GatherDemoWithSource for .dem files and compressed ones.
+// A compressed demo is decompressed to a temporary file, which is removed
+// once parsed; the match still names the archive it came from. The file
+// gets the archive's modification time and its .dem.info sidecar, which
+// cs-demo-analyzer reads the match date from when the demo has none.
+func gatherDemoFile(path string, source constants.DemoSource) (*api.Match, error) {
+	ext := compressedDemoExt(path)
+	if ext == "" {
+		return GatherDemoWithSource(path, source)
+	}
+
+	dir, err := os.MkdirTemp("", "manalyzer-demo-")
+	if err != nil {
+		return nil, fmt.Errorf("cannot create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Keep the demo's name, which source detection and DemoFileName use
+	name := filepath.Base(path)
+	name = name[:len(name)-len(ext)] + ".dem"
+	demoPath := filepath.Join(dir, name)
+	if err := decompressDemo(path, ext, demoPath); err != nil {
+		return nil, fmt.Errorf("cannot decompress %s: %w", path, err)
+	}
+	if info, err := os.Stat(path); err == nil {
+		if err := os.Chtimes(demoPath, info.ModTime(), info.ModTime()); err != nil {
+			LogWarn("Cannot keep the modification time of %s: %v", path, err)
+		}
+	}
+	infoPath := path[:len(path)-len(ext)] + ".dem.info"
+	if err := copyFile(infoPath, demoPath+".info"); err != nil && !os.IsNotExist(err) {
+		LogWarn("Cannot copy %s: %v", infoPath, err)
+	}
+	LogInfo("Decompressed %s", path)
+
+	match, err := GatherDemoWithSource(demoPath, source)
+	if err != nil {
+		return nil, err
+	}
+	match.DemoFilePath = path
+	return match, nil
+}
+
+// decompressDemo writes the demo compressed in path with ext to dst.
+func decompressDemo(path, ext, dst string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	var r io.Reader
+	switch ext {
+	case demoExtBzip2:
+		r = bzip2.NewReader(in)
+	case demoExtGzip:
+		gz, err := gzip.NewReader(in)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	default:
+		return fmt.Errorf("unknown compression %s", ext)
+	}
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	n, err := io.Copy(out, io.LimitReader(r, maxDemoSize+1))
+	if err == nil && n > maxDemoSize {
+		err = fmt.Errorf("decompressed demo is larger than %d GiB", maxDemoSize>>30)
+	}
+	if err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// copyFile copies the file at src to dst.
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
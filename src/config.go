@@ -0,0 +1,290 @@
+package manalyzer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/akiver/cs-demo-analyzer/pkg/api/constants"
+)
+
+const configFileName = "config.json"
+
+// configWriteMu serializes SaveConfig so concurrent saves can't interleave.
+var configWriteMu sync.Mutex
+
+// Average modes for ADR/KAST aggregation in OverallStatistics.
+const (
+	AverageModeMean   = "mean"
+	AverageModeMedian = "median"
+)
+
+// Log targets for Preferences.LogTarget.
+const (
+	LogTargetFile   = "file"
+	LogTargetSyslog = "syslog"
+	LogTargetStdout = "stdout"
+)
+
+// Log levels for Preferences.LogLevel, from most to least verbose.
+const (
+	LogLevelDebug = "debug"
+	LogLevelInfo  = "info"
+	LogLevelWarn  = "warn"
+	LogLevelError = "error"
+)
+
+// DemoSourceAuto lets cs-demo-analyzer detect each demo's source.
+const DemoSourceAuto = "auto"
+
+// PlayerConfig is a tracked player as stored in the config file.
+type PlayerConfig struct {
+	Name      string `json:"name"`
+	SteamID64 string `json:"steamId64"`
+
+	// Alias is a short display name shown instead of the demo name.
+	Alias string `json:"alias,omitempty"`
+}
+
+// TeamConfig is a named roster of players that can be loaded into the form
+// in one go.
+type TeamConfig struct {
+	Name    string         `json:"name"`
+	Players []PlayerConfig `json:"players"`
+}
+
+// Preferences holds settings that change how statistics are aggregated.
+type Preferences struct {
+	// AverageMode selects how overall ADR/KAST are aggregated: "mean" is the
+	// round-weighted average; "median" takes the median of per-match values,
+	// which needs every match sample kept in memory instead of the running
+	// weighted average.
+	AverageMode string `json:"averageMode"`
+
+	// LogTarget is where logs go: "file" (default), "syslog" (Unix only,
+	// falls back to the file), or "stdout".
+	LogTarget string `json:"logTarget"`
+
+	// LogLevel is the least severe message logged: "debug", "info"
+	// (default), "warn" or "error". Panics are always logged.
+	LogLevel string `json:"logLevel"`
+
+	// LogMaxSizeMB is the size at which manalyzer.log is rotated to
+	// manalyzer.log.1; the last logBackups old logs are kept.
+	LogMaxSizeMB int `json:"logMaxSizeMb"`
+
+	// ExcludeSteamIDs lists SteamID64s (bots, cheaters, smurfs) whose kills,
+	// deaths, damage and flashes are left out of every stat. Deaths to them
+	// still count, without a killer. Invalid entries are logged and skipped.
+	ExcludeSteamIDs []string `json:"excludeSteamIds,omitempty"`
+
+	// CountFlashAssists counts flash assists toward Assists and KAST
+	// (default). When false only damage assists count.
+	CountFlashAssists bool `json:"countFlashAssists"`
+
+	// DemoCache keeps parsed demos on disk next to the config (default), so
+	// unchanged demos aren't parsed again in later sessions.
+	DemoCache bool `json:"demoCache"`
+
+	// DemoSource is the platform demos are parsed as: a cs-demo-analyzer
+	// source such as "valve" (default) or "faceit", or "auto" to detect it
+	// per demo. It decides how rounds and sides are read, so a wrong source
+	// skews KAST and trades.
+	DemoSource string `json:"demoSource"`
+
+	// TimeZone is an IANA zone name (e.g. "Europe/Helsinki") for Event Log
+	// timestamps and saved results. Empty means local time.
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+// Location returns the zone named by TimeZone, or time.Local if it is empty
+// or unknown.
+func (p Preferences) Location() *time.Location {
+	if p.TimeZone == "" {
+		return time.Local
+	}
+	loc, err := time.LoadLocation(p.TimeZone)
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
+
+// ParserSource returns the source to parse demos as; empty means detect it.
+func (p Preferences) ParserSource() constants.DemoSource {
+	if p.DemoSource == DemoSourceAuto {
+		return ""
+	}
+	return constants.DemoSource(p.DemoSource)
+}
+
+// ViewPreset is a named statistics table filter and sort order.
+type ViewPreset struct {
+	Name       string `json:"name"`
+	MapFilter  string `json:"mapFilter,omitempty"`  // Empty shows every map
+	SideFilter string `json:"sideFilter,omitempty"` // "T", "CT" or empty for both
+	SortColumn string `json:"sortColumn,omitempty"` // A leaderboard metric; empty sorts by name
+	SortDesc   bool   `json:"sortDesc,omitempty"`
+}
+
+// Config is the persisted application configuration.
+type Config struct {
+	Players     []PlayerConfig `json:"players"`
+	BasePath    string         `json:"basePath"`
+	Preferences Preferences    `json:"preferences"`
+	ViewPresets []ViewPreset   `json:"viewPresets,omitempty"`
+	Teams       []TeamConfig   `json:"teams,omitempty"`
+
+	// LastView is the statistics table's filter and sort order when the
+	// application last exited, restored on the next start. Nil is the
+	// default view.
+	LastView *ViewPreset `json:"lastView,omitempty"`
+
+	// SteamAPIKey is an optional Steam Web API key, needed to resolve
+	// steamcommunity.com/id/ vanity URLs entered as players.
+	SteamAPIKey string `json:"steamApiKey,omitempty"`
+}
+
+// DefaultConfig returns the configuration used when no config file exists.
+func DefaultConfig() *Config {
+	return &Config{
+		Players: make([]PlayerConfig, 0, 5),
+		Preferences: Preferences{
+			AverageMode:  AverageModeMean,
+			LogTarget:    LogTargetFile,
+			LogLevel:     LogLevelInfo,
+			LogMaxSizeMB: defaultLogMaxSizeMB,
+
+			CountFlashAssists: true,
+			DemoCache:         true,
+			DemoSource:        string(constants.DemoSourceValve),
+		},
+	}
+}
+
+// configPath returns the location of config.json.
+func configPath() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, configFileName), nil
+}
+
+// LoadConfig reads config.json, returning DefaultConfig if it does not exist.
+func LoadConfig() (*Config, error) {
+	path, err := configPath()
+	if err != nil {
+		return DefaultConfig(), err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return DefaultConfig(), nil
+	}
+	if err != nil {
+		return DefaultConfig(), fmt.Errorf("cannot read config: %w", err)
+	}
+
+	cfg := DefaultConfig()
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return DefaultConfig(), fmt.Errorf("invalid config %s: %w", path, err)
+	}
+
+	if cfg.Preferences.AverageMode != AverageModeMedian {
+		cfg.Preferences.AverageMode = AverageModeMean
+	}
+	switch cfg.Preferences.LogTarget {
+	case LogTargetFile, LogTargetSyslog, LogTargetStdout:
+	default:
+		cfg.Preferences.LogTarget = LogTargetFile
+	}
+	if cfg.Preferences.LogMaxSizeMB <= 0 {
+		cfg.Preferences.LogMaxSizeMB = defaultLogMaxSizeMB
+	}
+	switch cfg.Preferences.LogLevel {
+	case LogLevelDebug, LogLevelInfo, LogLevelWarn, LogLevelError:
+	default:
+		cfg.Preferences.LogLevel = LogLevelInfo
+	}
+	if cfg.Preferences.DemoSource != DemoSourceAuto &&
+		!slices.Contains(constants.SupportedDemoSources, constants.DemoSource(cfg.Preferences.DemoSource)) {
+		cfg.Preferences.DemoSource = string(constants.DemoSourceValve)
+	}
+
+	return cfg, nil
+}
+
+// SaveConfig writes cfg to config.json, creating the config directory if needed.
+// The file is written to a temporary file and renamed into place, so readers
+// never see a partially written config.
+func SaveConfig(cfg *Config) error {
+	if cfg == nil {
+		return fmt.Errorf("config is nil")
+	}
+
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("cannot create config directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return fmt.Errorf("cannot encode config: %w", err)
+	}
+
+	configWriteMu.Lock()
+	defer configWriteMu.Unlock()
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), configFileName+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("cannot write config: %w", err)
+	}
+	tmpPath := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("cannot write config: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("cannot write config: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("cannot replace config: %w", err)
+	}
+
+	return nil
+}
+
+// ResetConfig replaces config.json with DefaultConfig. An existing config is
+// first copied to config.json.bak so a bad reset can be undone by hand.
+func ResetConfig() error {
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := os.WriteFile(path+".bak", data, 0o644); err != nil {
+			return fmt.Errorf("cannot back up config: %w", err)
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return fmt.Errorf("cannot read config: %w", err)
+	}
+
+	return SaveConfig(DefaultConfig())
+}
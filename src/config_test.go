@@ -0,0 +1,114 @@
+package manalyzer
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	_ "time/tzdata" // Zones for TestTimeZoneLocation on systems without them
+)
+
+func TestConcurrentConfigSaves(t *testing.T) {
+	dir := useTestHome(t)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cfg := DefaultConfig()
+			cfg.BasePath = fmt.Sprintf("/demos/%d", i)
+			errs <- SaveConfig(cfg)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("config after concurrent saves: %v", err)
+	}
+	var found bool
+	for i := range 20 {
+		found = found || cfg.BasePath == fmt.Sprintf("/demos/%d", i)
+	}
+	if !found {
+		t.Errorf("BasePath = %q, want one of the saved values", cfg.BasePath)
+	}
+	if tmps, _ := filepath.Glob(filepath.Join(dir, configFileName+".*.tmp")); len(tmps) > 0 {
+		t.Errorf("temporary files left behind: %v", tmps)
+	}
+	if _, err := os.Stat(filepath.Join(dir, configFileName)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestResetConfig(t *testing.T) {
+	dir := useTestHome(t)
+	cfg := DefaultConfig()
+	cfg.BasePath = "/demos"
+	cfg.Players = []PlayerConfig{{Name: "alice", SteamID64: "76561198000000001"}}
+	if err := SaveConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+	saved, err := os.ReadFile(filepath.Join(dir, configFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ResetConfig(); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, DefaultConfig()) {
+		t.Errorf("config after reset = %+v, want the defaults", got)
+	}
+	if backup, err := os.ReadFile(filepath.Join(dir, configFileName+".bak")); err != nil || !bytes.Equal(backup, saved) {
+		t.Errorf("backup = %q (%v), want the previous config", backup, err)
+	}
+}
+
+func TestTimeZoneLocation(t *testing.T) {
+	useTestHome(t)
+	fixed := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		zone string
+		want string // Export file name for fixed
+	}{
+		{"UTC", "results-20240601-120000.csv"},
+		{"Europe/Helsinki", "results-20240601-150000.csv"},
+	}
+	for _, tt := range tests {
+		loc := Preferences{TimeZone: tt.zone}.Location()
+		if loc.String() != tt.zone {
+			t.Errorf("Location() for %q = %v", tt.zone, loc)
+		}
+		path, err := exportPath(fixed.In(loc), ".csv")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := filepath.Base(path); got != tt.want {
+			t.Errorf("%s: export file %s, want %s", tt.zone, got, tt.want)
+		}
+	}
+
+	for _, zone := range []string{"", "Not/AZone"} {
+		if loc := (Preferences{TimeZone: zone}).Location(); loc != time.Local {
+			t.Errorf("Location() for %q = %v, want local time", zone, loc)
+		}
+	}
+}
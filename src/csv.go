@@ -0,0 +1,282 @@
+package manalyzer
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// csvStatColumns are the stat columns of a result CSV, named after the JSON
+// keys of SideStatistics, MapStatistics and OverallStatistics. A row leaves
+// a column empty when its kind of stats has no such field.
+var csvStatColumns = []string{
+	"matchesPlayed", "roundsPlayed",
+	"kast", "adr", "kd", "killDeathDiffPerRound", "rws",
+	"kills", "deaths", "assists", "flashAssists", "headshots",
+	"firstKills", "firstDeaths", "tradeKills", "tradeDeaths", "timesTradedFor",
+	"flashesThrown", "enemiesFlashed", "flashEfficiency", "utilityDamage",
+	"firstKillRoundsWon", "firstDeathRoundsWon",
+	"openingKillRoundWinRate", "openingDeathRoundWinRate", "openingDuelWinRate",
+	"hasDamageData", "damageRounds",
+	"kastViaKill", "kastViaAssist", "kastViaSurvive", "kastViaTrade",
+	"kills2", "kills3", "kills4", "kills5",
+	"roundsWon", "roundWinRate", "pistolRoundsPlayed", "pistolRoundsWon", "pistolRoundWinRate",
+}
+
+// csvHeader is the full header row: player and row identity, then the stats.
+var csvHeader = append([]string{"steamId64", "playerName", "map", "side"}, csvStatColumns...)
+
+// WriteCSV writes result as CSV with one row per player and map side, one
+// per map (only matchesPlayed is set) and one for the player's overall stats
+// (map and side empty). Per-phase breakdowns and median samples are not
+// included.
+func WriteCSV(result *WrangleResult, w io.Writer) error {
+	if result == nil {
+		return fmt.Errorf("no results to export")
+	}
+
+	players := slices.DeleteFunc(slices.Clone(result.PlayerStats), func(p *PlayerStats) bool { return p == nil })
+	sort.SliceStable(players, func(i, j int) bool {
+		return players[i].PlayerName < players[j].PlayerName
+	})
+
+	cw := csv.NewWriter(w)
+	cw.Write(csvHeader)
+	for _, playerStats := range players {
+		identity := func(mapName, side string) []string {
+			return []string{playerStats.SteamID64, playerStats.PlayerName, mapName, side}
+		}
+
+		mapNames := make([]string, 0, len(playerStats.MapStats))
+		for mapName := range playerStats.MapStats {
+			mapNames = append(mapNames, mapName)
+		}
+		sort.Strings(mapNames)
+
+		for _, mapName := range mapNames {
+			mapStats := playerStats.MapStats[mapName]
+			for _, side := range []string{"T", "CT"} {
+				if sideStats := mapStats.SideStats[side]; sideStats != nil {
+					if err := writeCSVRow(cw, identity(mapName, side), sideStats); err != nil {
+						return err
+					}
+				}
+			}
+			matches := struct {
+				MatchesPlayed int `json:"matchesPlayed"`
+			}{mapStats.MatchesPlayed}
+			if err := writeCSVRow(cw, identity(mapName, ""), matches); err != nil {
+				return err
+			}
+		}
+
+		if playerStats.OverallStats != nil {
+			if err := writeCSVRow(cw, identity("", ""), playerStats.OverallStats); err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ExportCSV writes result to path with WriteCSV, creating the directory if
+// needed.
+func ExportCSV(result *WrangleResult, path string) error {
+	if result == nil {
+		return fmt.Errorf("no results to export")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("cannot create export directory: %w", err)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("cannot create CSV: %w", err)
+	}
+	if err := WriteCSV(result, f); err != nil {
+		f.Close()
+		return fmt.Errorf("cannot write CSV: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("cannot write CSV: %w", err)
+	}
+	return nil
+}
+
+// writeCSVRow writes identity followed by the csvStatColumns values of stats,
+// taken from its JSON encoding.
+func writeCSVRow(cw *csv.Writer, identity []string, stats any) error {
+	data, err := json.Marshal(stats)
+	if err != nil {
+		return fmt.Errorf("cannot encode stats: %w", err)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("cannot encode stats: %w", err)
+	}
+
+	row := identity
+	for _, column := range csvStatColumns {
+		row = append(row, string(fields[column]))
+	}
+	return cw.Write(row)
+}
+
+// ImportCSV reads a result written by WriteCSV, so shared results can be
+// viewed without the demos. Rows are told apart by their map and side
+// columns. The header must have exactly the WriteCSV columns, in any order.
+// Malformed rows are skipped and reported in the returned error alongside
+// the rest of the result; TotalMatches is the most matches any player played.
+func ImportCSV(r io.Reader) (*WrangleResult, error) {
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("cannot read CSV header: %w", err)
+	}
+
+	index := make(map[string]int, len(header))
+	for i, column := range header {
+		if !slices.Contains(csvHeader, column) {
+			return nil, fmt.Errorf("unknown CSV column %q", column)
+		}
+		index[column] = i
+	}
+	var missing []string
+	for _, column := range csvHeader {
+		if _, ok := index[column]; !ok {
+			missing = append(missing, column)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("CSV is missing columns: %s", strings.Join(missing, ", "))
+	}
+
+	result := &WrangleResult{}
+	players := make(map[string]*PlayerStats)
+	maps := make(map[string]bool)
+	var errs []error
+
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			errs = append(errs, err) // Already says which line
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot read CSV: %w", err)
+		}
+		line, _ := cr.FieldPos(0)
+
+		if err := importCSVRow(record, index, players, result, maps); err != nil {
+			errs = append(errs, fmt.Errorf("line %d: %w", line, err))
+		}
+	}
+
+	for mapName := range maps {
+		result.MapList = append(result.MapList, mapName)
+	}
+	sort.Strings(result.MapList)
+	for _, playerStats := range result.PlayerStats {
+		if playerStats.OverallStats != nil {
+			result.TotalMatches = max(result.TotalMatches, playerStats.OverallStats.MatchesPlayed)
+		}
+	}
+	disambiguateNames(result.PlayerStats)
+	result.TopFraggerByMap = topFraggersByMap(result.PlayerStats)
+	restoreMaps(result)
+
+	return result, errors.Join(errs...)
+}
+
+// importCSVRow adds one CSV record to the player it belongs to, creating the
+// player in result on first sight.
+func importCSVRow(record []string, index map[string]int, players map[string]*PlayerStats, result *WrangleResult, maps map[string]bool) error {
+	cell := func(column string) string {
+		return strings.TrimSpace(record[index[column]])
+	}
+
+	steamID := cell("steamId64")
+	if steamID == "" {
+		return fmt.Errorf("missing steamId64")
+	}
+	mapName, side := cell("map"), cell("side")
+	if side != "" && side != "T" && side != "CT" {
+		return fmt.Errorf("invalid side %q", side)
+	}
+	if mapName == "" && side != "" {
+		return fmt.Errorf("side %s without a map", side)
+	}
+
+	// Rebuild the row's JSON object so the stats decode like a saved result
+	fields := make(map[string]json.RawMessage)
+	for _, column := range csvStatColumns {
+		value := cell(column)
+		if value == "" {
+			continue
+		}
+		// Only numbers and booleans belong in stat columns
+		if !json.Valid([]byte(value)) || value == "null" || strings.ContainsAny(value[:1], `"{[`) {
+			return fmt.Errorf("invalid %s value %q", column, value)
+		}
+		fields[column] = json.RawMessage(value)
+	}
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+
+	playerStats := players[steamID]
+	if playerStats == nil {
+		playerStats = &PlayerStats{SteamID64: steamID}
+		players[steamID] = playerStats
+		result.PlayerStats = append(result.PlayerStats, playerStats)
+	}
+	if playerStats.PlayerName == "" {
+		playerStats.PlayerName = cell("playerName")
+	}
+
+	if mapName == "" {
+		var overall OverallStatistics
+		if err := json.Unmarshal(data, &overall); err != nil {
+			return fmt.Errorf("invalid overall stats: %w", err)
+		}
+		playerStats.OverallStats = &overall
+		return nil
+	}
+
+	if playerStats.MapStats == nil {
+		playerStats.MapStats = make(map[string]*MapStatistics)
+	}
+	mapStats := playerStats.MapStats[mapName]
+	if mapStats == nil {
+		mapStats = &MapStatistics{MapName: mapName, SideStats: make(map[string]*SideStatistics)}
+		playerStats.MapStats[mapName] = mapStats
+	}
+	maps[mapName] = true
+
+	if side == "" {
+		if err := json.Unmarshal(data, mapStats); err != nil {
+			return fmt.Errorf("invalid map stats: %w", err)
+		}
+		return nil
+	}
+
+	sideStats := &SideStatistics{Side: side}
+	if err := json.Unmarshal(data, sideStats); err != nil {
+		return fmt.Errorf("invalid %s stats: %w", side, err)
+	}
+	mapStats.SideStats[side] = sideStats
+	return nil
+}
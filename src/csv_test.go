@@ -0,0 +1,84 @@
+package manalyzer
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/akiver/cs-demo-analyzer/pkg/api"
+)
+
+func TestCSVRoundTrip(t *testing.T) {
+	var matches []*api.Match
+	for _, mapName := range []string{"de_mirage", "de_inferno"} {
+		match := newTestMatch(mapName, 24)
+		for n := 1; n <= 24; n++ {
+			addDamage(match, n, 300, alice, testOpponent(match, alice, n), 45)
+			if n%3 == 0 {
+				addKill(match, n, 400, alice, testOpponent(match, alice, n))
+			}
+			if n%5 == 0 {
+				addKill(match, n, 500, testOpponent(match, bob, n), bob)
+			}
+		}
+		matches = append(matches, match)
+	}
+	original := processTestMatches(t, matches, alice, bob)
+
+	path := filepath.Join(t.TempDir(), "shared.csv")
+	if err := ExportCSV(original, path); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	imported, err := ImportCSV(f)
+	if err != nil {
+		t.Fatalf("ImportCSV: %v", err)
+	}
+
+	for _, steamID64 := range []uint64{alice, bob} {
+		want, got := testPlayerStats(t, original, steamID64), testPlayerStats(t, imported, steamID64)
+		if got.PlayerName != want.PlayerName || len(got.MapStats) != len(want.MapStats) {
+			t.Fatalf("imported %s with %d maps, want %s with %d", got.PlayerName, len(got.MapStats), want.PlayerName, len(want.MapStats))
+		}
+		for mapName, wantMap := range want.MapStats {
+			gotMap := got.MapStats[mapName]
+			if gotMap == nil || gotMap.MatchesPlayed != wantMap.MatchesPlayed {
+				t.Errorf("%s %s: imported %+v", want.PlayerName, mapName, gotMap)
+				continue
+			}
+			for side, wantSide := range wantMap.SideStats {
+				gotSide := gotMap.SideStats[side]
+				if gotSide == nil || gotSide.Kills != wantSide.Kills || gotSide.Deaths != wantSide.Deaths ||
+					gotSide.RoundsPlayed != wantSide.RoundsPlayed || !closeTo(gotSide.KAST, wantSide.KAST) || !closeTo(gotSide.ADR, wantSide.ADR) {
+					t.Errorf("%s %s %s: imported %+v, want %+v", want.PlayerName, mapName, side, gotSide, wantSide)
+				}
+			}
+		}
+		if got.OverallStats == nil || !closeTo(got.OverallStats.KAST, want.OverallStats.KAST) || !closeTo(got.OverallStats.ADR, want.OverallStats.ADR) {
+			t.Errorf("%s overall: imported %+v, want %+v", want.PlayerName, got.OverallStats, want.OverallStats)
+		}
+	}
+
+	// Every exported column survives the import
+	var first, second bytes.Buffer
+	if err := WriteCSV(original, &first); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteCSV(imported, &second); err != nil {
+		t.Fatal(err)
+	}
+	if first.String() != second.String() {
+		t.Errorf("re-exported CSV differs:\n%s\nwant:\n%s", second.String(), first.String())
+	}
+}
+
+// closeTo reports whether a and b are equal within rounding.
+func closeTo(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
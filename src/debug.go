@@ -0,0 +1,78 @@
+package manalyzer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/akiver/cs-demo-analyzer/pkg/api"
+)
+
+// DumpMatchStats writes a round-by-round breakdown of one player's match to
+// w: side, kills, deaths, damage dealt and which KAST conditions were met.
+// It uses the same helpers as ProcessMatches, so numbers can be checked by
+// hand against the demo.
+func DumpMatchStats(match *api.Match, steamID64 uint64, w io.Writer) error {
+	player, ok := match.PlayersBySteamID[steamID64]
+	if !ok {
+		return fmt.Errorf("player %d is not in %s", steamID64, match.DemoFileName)
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "%s on %s, %d rounds\n", match.DemoFileName, normalizeMapName(match.MapName), len(match.Rounds))
+	fmt.Fprintf(bw, "Player: %s (%d)\n\n", player.Name, steamID64)
+
+	for _, round := range match.Rounds {
+		side := sideToString(determinePlayerSideInRound(match, player, round))
+		if side == "" {
+			fmt.Fprintf(bw, "Round %d: not on a side\n", round.Number)
+			continue
+		}
+
+		var kills, deaths []string
+		for _, kill := range match.Kills {
+			if kill.RoundNumber != round.Number {
+				continue
+			}
+			if kill.KillerSteamID64 == player.SteamID64 && kill.VictimSteamID64 != player.SteamID64 {
+				kills = append(kills, fmt.Sprintf("%s (%s)", kill.VictimName, kill.WeaponName))
+			}
+			if kill.VictimSteamID64 == player.SteamID64 {
+				deaths = append(deaths, fmt.Sprintf("by %s (%s)", kill.KillerName, kill.WeaponName))
+			}
+		}
+
+		// Rounds are matched as in extractPlayerStatsBySide
+		damage := 0
+		for _, d := range match.Damages {
+			if d.AttackerSteamID64 == player.SteamID64 && damageRound(match, d) == round {
+				damage += d.HealthDamage
+			}
+		}
+
+		gotKill, gotAssist, survived, wasTraded := kastInRound(match, player, round)
+		kast := ""
+		for _, c := range []struct {
+			met  bool
+			flag string
+		}{{gotKill, "K"}, {gotAssist, "A"}, {survived, "S"}, {wasTraded, "T"}} {
+			if c.met {
+				kast += c.flag
+			}
+		}
+		if kast == "" {
+			kast = "-"
+		}
+
+		fmt.Fprintf(bw, "Round %d: side=%s damage=%d kast=%s\n", round.Number, side, damage, kast)
+		if len(kills) > 0 {
+			fmt.Fprintf(bw, "  kills: %s\n", strings.Join(kills, ", "))
+		}
+		if len(deaths) > 0 {
+			fmt.Fprintf(bw, "  death: %s\n", strings.Join(deaths, ", "))
+		}
+	}
+
+	return bw.Flush()
+}
@@ -0,0 +1,37 @@
+package manalyzer
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/akiver/cs-demo-analyzer/pkg/api/constants"
+)
+
+func TestDumpMatchStats(t *testing.T) {
+	match := newTestMatch("workshop/123/de_mirage", 2)
+	addKill(match, 1, 300, alice, carol).WeaponName = constants.WeaponAK47
+	addDamage(match, 1, 300, alice, carol, 100)
+	addKill(match, 2, 300, dave, alice).WeaponName = constants.WeaponAWP
+
+	var out bytes.Buffer
+	if err := DumpMatchStats(match, alice, &out); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		match.DemoFileName + " on de_mirage, 2 rounds",
+		"Player: alice (76561198000000001)",
+		"Round 1: side=CT damage=100 kast=KS",
+		"  kills: carol (AK-47)",
+		"Round 2: side=CT damage=0 kast=-",
+		"  death: by dave (AWP)",
+	} {
+		if !strings.Contains(out.String(), line+"\n") {
+			t.Errorf("dump lacks %q:\n%s", line, out.String())
+		}
+	}
+
+	if err := DumpMatchStats(match, 1, &out); err == nil {
+		t.Error("dumping a player not in the match succeeded")
+	}
+}
@@ -0,0 +1,250 @@
+package manalyzer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+
+	"github.com/akiver/cs-demo-analyzer/pkg/api"
+	"github.com/akiver/cs-demo-analyzer/pkg/api/constants"
+)
+
+// demoCacheVersion is bumped whenever the cached match layout, or the fields
+// kept by trimMatch, change; entries of other versions are ignored.
+const demoCacheVersion = 2
+
+const demoCacheDirName = "cache"
+
+// demoCacheEnabled turns the disk cache on; see SetDemoCacheEnabled.
+var demoCacheEnabled atomic.Bool
+
+// SetDemoCacheEnabled turns the on-disk demo cache on or off, normally from
+// Preferences.DemoCache. It is off until called.
+func SetDemoCacheEnabled(enabled bool) {
+	demoCacheEnabled.Store(enabled)
+}
+
+// cachedDemo is the on-disk envelope of a cached match. The api alias types
+// skip the custom MarshalJSON methods, which compute extra stats through
+// parser state a decoded match doesn't have.
+type cachedDemo struct {
+	Version int                         `json:"version"`
+	Match   *api.MatchAlias             `json:"match"` // Without players and rounds
+	Players map[uint64]*api.PlayerAlias `json:"players"`
+	Rounds  []*api.RoundAlias           `json:"rounds"`
+}
+
+// CacheStats describes the on-disk demo cache.
+type CacheStats struct {
+	Dir     string
+	Entries int
+	Bytes   int64
+}
+
+// demoCacheDir returns the cache directory next to the config file.
+func demoCacheDir() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, demoCacheDirName), nil
+}
+
+// demoCachePath returns the cache file for key. Like the session cache, it
+// is keyed by path, size, modification time and source rather than a
+// content hash, so checking the cache doesn't read the whole demo.
+func demoCachePath(key demoKey) (string, error) {
+	dir, err := demoCacheDir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(fmt.Appendf(nil, "%s|%d|%d|%s", key.path, key.size, key.modTime.UnixNano(), key.source))
+	return filepath.Join(dir, hex.EncodeToString(sum[:])+".json"), nil
+}
+
+// loadCachedDemo returns the cached match for key, if the cache is enabled
+// and holds a current entry.
+func loadCachedDemo(key demoKey) (*api.Match, bool) {
+	if !demoCacheEnabled.Load() {
+		return nil, false
+	}
+	path, err := demoCachePath(key)
+	if err != nil {
+		return nil, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+
+	var cached cachedDemo
+	if err := json.Unmarshal(data, &cached); err != nil || cached.Version != demoCacheVersion || cached.Match == nil {
+		return nil, false
+	}
+	match := (*api.Match)(cached.Match)
+	match.PlayersBySteamID = make(map[uint64]*api.Player, len(cached.Players))
+	for steamID64, player := range cached.Players {
+		match.PlayersBySteamID[steamID64] = (*api.Player)(player)
+	}
+	match.Rounds = make([]*api.Round, len(cached.Rounds))
+	for i, round := range cached.Rounds {
+		match.Rounds[i] = (*api.Round)(round)
+	}
+	relinkTeams(match)
+	return match, true
+}
+
+// storeCachedDemo writes match to the cache if it is enabled. Failures are
+// only logged: the cache is an optimization.
+func storeCachedDemo(key demoKey, match *api.Match) {
+	if !demoCacheEnabled.Load() {
+		return
+	}
+	path, err := demoCachePath(key)
+	if err != nil {
+		return
+	}
+	if err := writeCachedDemo(path, match); err != nil {
+		LogWarn("Cannot cache %s: %v", key.path, err)
+	}
+}
+
+func writeCachedDemo(path string, match *api.Match) error {
+	cached := cachedDemo{
+		Version: demoCacheVersion,
+		Match:   (*api.MatchAlias)(trimMatch(match)),
+		Players: make(map[uint64]*api.PlayerAlias, len(match.PlayersBySteamID)),
+		Rounds:  make([]*api.RoundAlias, len(match.Rounds)),
+	}
+	for steamID64, player := range match.PlayersBySteamID {
+		cached.Players[steamID64] = (*api.PlayerAlias)(player)
+	}
+	for i, round := range match.Rounds {
+		cached.Rounds[i] = (*api.RoundAlias)(round)
+	}
+	data, err := json.Marshal(cached)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+// trimMatch returns a copy of match with only the fields manalyzer reads,
+// leaving out the bulky ones (shots, positions, buys) it never uses.
+// Players and rounds are stored separately by writeCachedDemo.
+func trimMatch(match *api.Match) *api.Match {
+	return &api.Match{
+		Checksum:          match.Checksum,
+		Game:              match.Game,
+		DemoFilePath:      match.DemoFilePath,
+		DemoFileName:      match.DemoFileName,
+		Source:            match.Source,
+		Type:              match.Type,
+		MapName:           match.MapName,
+		TickCount:         match.TickCount,
+		TickRate:          match.TickRate,
+		FrameRate:         match.FrameRate,
+		Date:              match.Date,
+		Duration:          match.Duration,
+		MaxRounds:         match.MaxRounds,
+		OvertimeCount:     match.OvertimeCount,
+		TeamA:             match.TeamA,
+		TeamB:             match.TeamB,
+		Winner:            match.Winner,
+		Kills:             match.Kills,
+		Clutches:          match.Clutches,
+		BombsPlanted:      match.BombsPlanted,
+		BombsDefused:      match.BombsDefused,
+		PlayersFlashed:    match.PlayersFlashed,
+		FlashbangsExplode: match.FlashbangsExplode,
+		Damages:           match.Damages,
+		PlayerEconomies:   match.PlayerEconomies,
+	}
+}
+
+// relinkTeams points players and the winner back at the match's own teams.
+// Side detection compares Team pointers, but JSON decoding gives every
+// player a separate copy.
+func relinkTeams(match *api.Match) {
+	team := func(t *api.Team) *api.Team {
+		switch {
+		case t == nil:
+			return nil
+		case match.TeamA != nil && t.Letter == constants.TeamLetterA:
+			return match.TeamA
+		case match.TeamB != nil && t.Letter == constants.TeamLetterB:
+			return match.TeamB
+		}
+		return t
+	}
+	for _, player := range match.PlayersBySteamID {
+		player.Team = team(player.Team)
+	}
+	match.Winner = team(match.Winner)
+}
+
+// DemoCacheStats reports the size of the on-disk demo cache.
+func DemoCacheStats() (CacheStats, error) {
+	dir, err := demoCacheDir()
+	if err != nil {
+		return CacheStats{}, err
+	}
+	stats := CacheStats{Dir: dir}
+
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return stats, nil
+	}
+	if err != nil {
+		return stats, fmt.Errorf("cannot read demo cache: %w", err)
+	}
+	for _, entry := range entries {
+		if filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		if info, err := entry.Info(); err == nil {
+			stats.Entries++
+			stats.Bytes += info.Size()
+		}
+	}
+	return stats, nil
+}
+
+// ClearDemoCache deletes the on-disk demo cache. Demos parsed this session
+// stay cached in memory.
+func ClearDemoCache() error {
+	dir, err := demoCacheDir()
+	if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("cannot clear demo cache: %w", err)
+	}
+	return nil
+}
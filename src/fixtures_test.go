@@ -0,0 +1,179 @@
+package manalyzer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/akiver/cs-demo-analyzer/pkg/api"
+	"github.com/markus-wa/demoinfocs-golang/v4/pkg/demoinfocs/common"
+)
+
+// Players of newTestMatch. Alice and Bob are on team A, which starts as CT;
+// Carol and Dave are on team B.
+const (
+	alice uint64 = 76561198000000001
+	bob   uint64 = 76561198000000002
+	carol uint64 = 76561198000000003
+	dave  uint64 = 76561198000000004
+)
+
+var testPlayerNames = map[uint64]string{alice: "alice", bob: "bob", carol: "carol", dave: "dave"}
+
+// testRoundTicks is the length of a round in newTestMatch.
+const testRoundTicks = 1000
+
+// testMatches numbers the matches built, for unique checksums.
+var testMatches int
+
+// newTestMatch returns a match on mapName with the given number of rounds,
+// sides switching after round 12 and CT winning every round. Events are
+// added with addKill and addDamage.
+func newTestMatch(mapName string, rounds int) *api.Match {
+	testMatches++
+	teamA := &api.Team{Name: "Team A"}
+	teamB := &api.Team{Name: "Team B"}
+	match := &api.Match{
+		Checksum:         strconv.Itoa(testMatches),
+		DemoFilePath:     fmt.Sprintf("/demos/%s-%d.dem", mapName, testMatches),
+		DemoFileName:     fmt.Sprintf("%s-%d.dem", mapName, testMatches),
+		MapName:          mapName,
+		TickRate:         64,
+		MaxRounds:        2 * mr12HalfLength,
+		Date:             time.Date(2024, 1, 1, 20, 0, 0, 0, time.UTC).Add(time.Duration(testMatches) * time.Hour),
+		TeamA:            teamA,
+		TeamB:            teamB,
+		PlayersBySteamID: make(map[uint64]*api.Player),
+	}
+	for steamID64, name := range testPlayerNames {
+		team := teamA
+		if steamID64 == carol || steamID64 == dave {
+			team = teamB
+		}
+		match.PlayersBySteamID[steamID64] = &api.Player{SteamID64: steamID64, Name: name, Team: team}
+	}
+
+	for n := 1; n <= rounds; n++ {
+		sideA, sideB := common.TeamCounterTerrorists, common.TeamTerrorists
+		if n > mr12HalfLength {
+			sideA, sideB = sideB, sideA
+		}
+		start := (n - 1) * testRoundTicks
+		match.Rounds = append(match.Rounds, &api.Round{
+			Number:            n,
+			StartTick:         start,
+			FreezeTimeEndTick: start + 100,
+			EndTick:           start + testRoundTicks - 1,
+			TeamASide:         sideA,
+			TeamBSide:         sideB,
+			WinnerSide:        common.TeamCounterTerrorists,
+		})
+	}
+	return match
+}
+
+// testSide returns the side steamID64 plays in round n of a newTestMatch, or
+// TeamUnassigned for 0, the world.
+func testSide(match *api.Match, steamID64 uint64, n int) common.Team {
+	player := match.PlayersBySteamID[steamID64]
+	if player == nil {
+		return common.TeamUnassigned
+	}
+	return determinePlayerSideInRound(match, player, match.Rounds[n-1])
+}
+
+// addKill adds a kill of victim by killer, tick ticks into round n. A killer
+// of 0 is the world, e.g. fall damage.
+func addKill(match *api.Match, n, tick int, killer, victim uint64) *api.Kill {
+	kill := &api.Kill{
+		Tick:            (n-1)*testRoundTicks + tick,
+		RoundNumber:     n,
+		KillerSteamID64: killer,
+		KillerName:      testPlayerNames[killer],
+		KillerSide:      testSide(match, killer, n),
+		VictimSteamID64: victim,
+		VictimName:      testPlayerNames[victim],
+		VictimSide:      testSide(match, victim, n),
+	}
+	match.Kills = append(match.Kills, kill)
+	return kill
+}
+
+// addDamage adds health damage to victim by attacker, tick ticks into
+// round n.
+func addDamage(match *api.Match, n, tick int, attacker, victim uint64, health int) *api.Damage {
+	damage := &api.Damage{
+		Tick:              (n-1)*testRoundTicks + tick,
+		RoundNumber:       n,
+		HealthDamage:      health,
+		AttackerSteamID64: attacker,
+		AttackerSide:      testSide(match, attacker, n),
+		VictimSteamID64:   victim,
+		VictimSide:        testSide(match, victim, n),
+	}
+	match.Damages = append(match.Damages, damage)
+	return damage
+}
+
+// testPreferences returns the default preferences without the disk cache.
+func testPreferences() Preferences {
+	prefs := DefaultConfig().Preferences
+	prefs.DemoCache = false
+	return prefs
+}
+
+// processTestMatches runs ProcessMatches with testPreferences for the given
+// players.
+func processTestMatches(t *testing.T, matches []*api.Match, players ...uint64) *WrangleResult {
+	t.Helper()
+	steamIDs := make([]string, len(players))
+	for i, steamID64 := range players {
+		steamIDs[i] = strconv.FormatUint(steamID64, 10)
+	}
+	result, err := ProcessMatches(context.Background(), matches, steamIDs, testPreferences(), [2]int{})
+	if err != nil {
+		t.Fatalf("ProcessMatches: %v", err)
+	}
+	return result
+}
+
+// testPlayerStats returns steamID64's stats in result.
+func testPlayerStats(t *testing.T, result *WrangleResult, steamID64 uint64) *PlayerStats {
+	t.Helper()
+	for _, playerStats := range result.PlayerStats {
+		if playerStats.SteamID64 == strconv.FormatUint(steamID64, 10) {
+			return playerStats
+		}
+	}
+	t.Fatalf("no stats for %d", steamID64)
+	return nil
+}
+
+// writeTestDemo creates an empty demo file for match in dir and caches match
+// as its parse, so gathering dir yields match without a real demo.
+func writeTestDemo(t *testing.T, dir string, match *api.Match) {
+	t.Helper()
+	path := filepath.Join(dir, match.DemoFileName)
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := demoKey{path: path, size: info.Size(), modTime: info.ModTime(), source: testPreferences().ParserSource()}
+	parsedDemos.put(key, match)
+	t.Cleanup(func() { parsedDemos.remove(key) })
+}
+
+// testOpponent returns a player on the other side from steamID64 in round n.
+func testOpponent(match *api.Match, steamID64 uint64, n int) uint64 {
+	if testSide(match, steamID64, n) == testSide(match, carol, n) {
+		return alice
+	}
+	return carol
+}
@@ -3,11 +3,17 @@
 package manalyzer
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"runtime"
+	"slices"
+	"sync"
+	"time"
 
 	"github.com/akiver/cs-demo-analyzer/pkg/api"
 	"github.com/akiver/cs-demo-analyzer/pkg/api/constants"
@@ -15,11 +21,42 @@ import (
 
 var ErrNoDemos = errors.New("no .dem files found")
 
-// GatherDemo analyzes a single demo file and returns match statistics.
+// ErrPOVDemo marks demos skipped because they were recorded from a single
+// player's point of view. Their side and round data is incomplete, so mixing
+// them with GOTV demos would skew the stats.
+var ErrPOVDemo = errors.New("POV demo skipped, only GOTV demos are analyzed")
+
+// MatchInfo identifies a parsed match.
+type MatchInfo struct {
+	DemoFileName string             `json:"demoFileName"`
+	MapName      string             `json:"mapName"`
+	Date         time.Time          `json:"date"`
+	Rounds       int                `json:"rounds"`
+	DemoKind     constants.DemoType `json:"demoKind"` // GOTV or POV
+}
+
+// NewMatchInfo describes match.
+func NewMatchInfo(match *api.Match) MatchInfo {
+	return MatchInfo{
+		DemoFileName: match.DemoFileName,
+		MapName:      normalizeMapName(match.MapName),
+		Date:         match.Date,
+		Rounds:       len(match.Rounds),
+		DemoKind:     match.Type,
+	}
+}
+
+// GatherDemo analyzes a single Valve demo file and returns match statistics.
 func GatherDemo(demoPath string) (*api.Match, error) {
+	return GatherDemoWithSource(demoPath, constants.DemoSourceValve)
+}
+
+// GatherDemoWithSource is GatherDemo for a demo recorded by source, such as
+// FACEIT or ESEA. An empty source lets cs-demo-analyzer detect it.
+func GatherDemoWithSource(demoPath string, source constants.DemoSource) (*api.Match, error) {
 	match, err := api.AnalyzeDemo(demoPath, api.AnalyzeDemoOptions{
 		IncludePositions: false,
-		Source:           constants.DemoSourceValve,
+		Source:           source,
 	})
 
 	if err != nil {
@@ -29,12 +66,233 @@ func GatherDemo(demoPath string) (*api.Match, error) {
 	return match, nil
 }
 
-// GatherAllDemosFromPath recursively finds and analyzes all .dem files in basePath.
-func GatherAllDemosFromPath(basePath string) ([]*api.Match, error) {
+// Gather statuses for GatherEntry.Status.
+const (
+	GatherStatusOK      = "ok"
+	GatherStatusFailed  = "failed"
+	GatherStatusSkipped = "skipped" // Parsed but not analyzed, e.g. POV demos
+)
+
+// GatherEntry is the outcome for one demo file.
+type GatherEntry struct {
+	Path   string     `json:"path"`
+	Status string     `json:"status"`
+	Match  *MatchInfo `json:"match,omitempty"`
+	Error  string     `json:"error,omitempty"`
+}
+
+// GatherReport lists what happened to every demo found by a gather.
+type GatherReport struct {
+	Entries []GatherEntry `json:"entries"`
+}
+
+// Count returns the number of entries with the given status.
+func (r *GatherReport) Count(status string) int {
+	if r == nil {
+		return 0
+	}
+	n := 0
+	for _, entry := range r.Entries {
+		if entry.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+func (r *GatherReport) add(path, status string, match *api.Match, err error) {
+	entry := GatherEntry{Path: path, Status: status}
+	if match != nil {
+		info := NewMatchInfo(match)
+		entry.Match = &info
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	r.Entries = append(r.Entries, entry)
+}
+
+// WriteGatherReport writes report to path as indented JSON.
+func WriteGatherReport(report *GatherReport, path string) error {
+	if report == nil {
+		return fmt.Errorf("no gather report to write")
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("cannot encode gather report: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("cannot write gather report: %w", err)
+	}
+	return nil
+}
+
+// GatherAllDemosFromPath recursively finds and analyzes all demos in basePath,
+// including compressed ones, as Valve demos, parsing GOMAXPROCS demos at
+// once. Demos already parsed this session are reused unless the file changed.
+func GatherAllDemosFromPath(ctx context.Context, basePath string) ([]*api.Match, error) {
+	matches, _, err := GatherAllDemosWithReport(ctx, basePath)
+	return matches, err
+}
+
+// GatherAllDemosWithReport is GatherAllDemosFromPath, also returning a report
+// of every demo found. The report is nil when basePath itself is unusable.
+func GatherAllDemosWithReport(ctx context.Context, basePath string) ([]*api.Match, *GatherReport, error) {
+	return GatherLatestDemosWithReport(ctx, basePath, 0, constants.DemoSourceValve)
+}
+
+// GatherLatestDemosWithReport is GatherAllDemosWithReport limited to the
+// latestN most recently modified demos; 0 gathers all of them. Older demos
+// are not parsed and don't appear in the report. Demos are parsed as source,
+// or detected when it is empty.
+func GatherLatestDemosWithReport(ctx context.Context, basePath string, latestN int, source constants.DemoSource) ([]*api.Match, *GatherReport, error) {
+	return gatherDemos(ctx, basePath, latestN, 0, source)
+}
+
+// GatherAllDemosFromPathWithWorkers is GatherAllDemosFromPath parsing up to
+// workers demos at once; 0 or less uses GOMAXPROCS.
+func GatherAllDemosFromPathWithWorkers(ctx context.Context, basePath string, workers int) ([]*api.Match, error) {
+	matches, _, err := gatherDemos(ctx, basePath, 0, workers, constants.DemoSourceValve)
+	return matches, err
+}
+
+// gatherDemos lists the demos in basePath, keeps the latestN newest (0 keeps
+// all) and parses them as source with up to workers at once (0 uses
+// GOMAXPROCS).
+// Matches, report entries and errors keep the listing order whatever order
+// the demos finish in. Once ctx is done no more demos are started and only
+// ctx's error is returned; demos already being parsed run to completion.
+func gatherDemos(ctx context.Context, basePath string, latestN, workers int, source constants.DemoSource) ([]*api.Match, *GatherReport, error) {
 	var matches []*api.Match
 	var errs []error
-	var demoCount int
+	report := &GatherReport{}
+
+	demos, err := listDemos(ctx, basePath)
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, nil, ctxErr
+	}
+	if demos == nil && err != nil {
+		return nil, nil, err
+	}
+	if err != nil {
+		errs = append(errs, err)
+	}
+	if len(demos) == 0 {
+		return nil, report, ErrNoDemos
+	}
+	demos = latestDemos(demos, latestN)
+	parsed := parseDemos(ctx, demos, workers, source)
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	for i, demo := range demos {
+		if demo.err != nil {
+			errs = append(errs, fmt.Errorf("failed to analyze %s: %w", demo.path, demo.err))
+			report.add(demo.path, GatherStatusFailed, nil, demo.err)
+			continue
+		}
+
+		match, err := parsed[i].match, parsed[i].err
+		if err != nil {
+			errMsg := fmt.Errorf("failed to analyze %s: %w", demo.path, err)
+			errs = append(errs, errMsg)
+			report.add(demo.path, GatherStatusFailed, nil, err)
+			continue
+		}
+
+		if match.Type == constants.DemoTypePOV {
+			errs = append(errs, fmt.Errorf("%s: %w", demo.path, ErrPOVDemo))
+			report.add(demo.path, GatherStatusSkipped, match, ErrPOVDemo)
+			continue
+		}
+
+		matches = append(matches, match)
+		report.add(demo.path, GatherStatusOK, match, nil)
+	}
+
+	if len(matches) == 0 && len(errs) > 0 {
+		return nil, report, fmt.Errorf("all %d demos failed to parse: %w", len(demos), errors.Join(errs...))
+	}
+
+	if len(errs) > 0 {
+		return matches, report, errors.Join(errs...)
+	}
+
+	return matches, report, nil
+}
+
+// demoResult is the outcome of parsing one demoFile.
+type demoResult struct {
+	match *api.Match
+	err   error
+}
+
+// parseDemos parses demos with a pool of workers goroutines (GOMAXPROCS when
+// workers is 0 or less). Each result is stored at its demo's index, so no
+// locking is needed. Demos whose file info failed are left unparsed, as are
+// those not yet started when ctx is done.
+func parseDemos(ctx context.Context, demos []demoFile, workers int, source constants.DemoSource) []demoResult {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	workers = min(workers, len(demos))
+
+	parsed := make([]demoResult, len(demos))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				parsed[i] = parseDemo(demos[i], source)
+			}
+		}()
+	}
+feed:
+	for i, demo := range demos {
+		if demo.err != nil {
+			continue
+		}
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	return parsed
+}
+
+// parseDemo parses demo through the session cache. A panic in the parser is
+// returned as an error, since nothing above a worker goroutine recovers it.
+func parseDemo(demo demoFile, source constants.DemoSource) (result demoResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			LogPanic(r)
+			result = demoResult{err: fmt.Errorf("parser panic: %v", r)}
+		}
+	}()
+
+	match, err := gatherDemoCached(demo.path, demo.info, source)
+	return demoResult{match: match, err: err}
+}
 
+// demoFile is a demo found by listDemos, possibly compressed. err is set
+// when its file info could not be read.
+type demoFile struct {
+	path string
+	info os.FileInfo
+	err  error
+}
+
+// listDemos recursively finds the .dem files in basePath, and .dem.bz2 and
+// .dem.gz ones, without parsing them. A walk error is returned alongside the
+// demos found before it; the walk stops early when ctx is done.
+func listDemos(ctx context.Context, basePath string) ([]demoFile, error) {
 	if basePath == "" {
 		return nil, fmt.Errorf("base path is empty")
 	}
@@ -50,7 +308,11 @@ func GatherAllDemosFromPath(basePath string) ([]*api.Match, error) {
 		return nil, fmt.Errorf("base path is not a directory: %s", basePath)
 	}
 
+	var demos []demoFile
 	err = filepath.WalkDir(basePath, func(path string, d fs.DirEntry, err error) error {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
 		if err != nil {
 			return nil
 		}
@@ -59,41 +321,41 @@ func GatherAllDemosFromPath(basePath string) ([]*api.Match, error) {
 			return nil
 		}
 
-		if filepath.Ext(path) != ".dem" {
-			return nil
-		}
-
-		demoCount++
-
-		match, err := GatherDemo(path)
-		if err != nil {
-			errMsg := fmt.Errorf("failed to analyze %s: %w", path, err)
-			errs = append(errs, errMsg)
+		if !isDemoFile(path) {
 			return nil
 		}
 
-		matches = append(matches, match)
-
+		info, err := d.Info()
+		demos = append(demos, demoFile{path: path, info: info, err: err})
 		return nil
 	})
-
 	if err != nil {
-		errs = append(errs, fmt.Errorf("directory walk error: %w", err))
-	}
-
-	if demoCount == 0 {
-		return nil, ErrNoDemos
+		return demos, fmt.Errorf("directory walk error: %w", err)
 	}
+	return demos, nil
+}
 
-	if len(matches) == 0 && len(errs) > 0 {
-		return nil, fmt.Errorf("all %d demos failed to parse: %w", demoCount, errors.Join(errs...))
+// latestDemos returns the n most recently modified demos, newest first, or
+// all of them in their original order when n is 0 or covers every demo.
+// Demos whose info couldn't be read count as oldest.
+func latestDemos(demos []demoFile, n int) []demoFile {
+	if n <= 0 || n >= len(demos) {
+		return demos
 	}
 
-	if len(errs) > 0 {
-		return matches, errors.Join(errs...)
-	}
-
-	return matches, nil
+	sorted := slices.Clone(demos)
+	slices.SortStableFunc(sorted, func(a, b demoFile) int {
+		switch {
+		case a.info == nil && b.info == nil:
+			return 0
+		case a.info == nil:
+			return 1
+		case b.info == nil:
+			return -1
+		}
+		return b.info.ModTime().Compare(a.info.ModTime())
+	})
+	return sorted[:n]
 }
 
 // GatherAllDemos finds and analyzes all .dem files in the current directory.
@@ -115,6 +377,10 @@ func GatherAllDemos() ([]*api.Match, error) {
 			errs = append(errs, fmt.Errorf("%s: %w", path, err))
 			continue
 		}
+		if match.Type == constants.DemoTypePOV {
+			errs = append(errs, fmt.Errorf("%s: %w", path, ErrPOVDemo))
+			continue
+		}
 		matches = append(matches, match)
 	}
 
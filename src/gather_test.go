@@ -0,0 +1,149 @@
+package manalyzer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/akiver/cs-demo-analyzer/pkg/api"
+	"github.com/akiver/cs-demo-analyzer/pkg/api/constants"
+)
+
+// gatherTestDemos gathers dir like the UI does with testPreferences.
+func gatherTestDemos(t *testing.T, dir string, latestN int) ([]*api.Match, *GatherReport, error) {
+	t.Helper()
+	return GatherLatestDemosWithReport(context.Background(), dir, latestN, testPreferences().ParserSource())
+}
+
+func TestGatherSkipsPOVDemos(t *testing.T) {
+	dir := t.TempDir()
+	gotv, pov := newTestMatch("de_mirage", 24), newTestMatch("de_nuke", 24)
+	gotv.Type = constants.DemoTypeGOTV
+	pov.Type = constants.DemoTypePOV
+	writeTestDemo(t, dir, gotv)
+	writeTestDemo(t, dir, pov)
+
+	matches, report, err := gatherTestDemos(t, dir, 0)
+	if !errors.Is(err, ErrPOVDemo) {
+		t.Errorf("err = %v, want ErrPOVDemo", err)
+	}
+	if len(matches) != 1 || matches[0] != gotv {
+		t.Errorf("gathered %d matches, want only the GOTV demo", len(matches))
+	}
+	if report.Count(GatherStatusOK) != 1 || report.Count(GatherStatusSkipped) != 1 {
+		t.Fatalf("report = %+v, want one ok and one skipped demo", report.Entries)
+	}
+	for _, entry := range report.Entries {
+		want := constants.DemoTypeGOTV
+		if entry.Status == GatherStatusSkipped {
+			want = constants.DemoTypePOV
+		}
+		if entry.Match == nil || entry.Match.DemoKind != want {
+			t.Errorf("%s entry for %s has match %+v, want kind %s", entry.Status, entry.Path, entry.Match, want)
+		}
+	}
+}
+
+func TestWriteGatherReport(t *testing.T) {
+	dir := t.TempDir()
+	match := newTestMatch("DE_Mirage", 24)
+	match.Type = constants.DemoTypeGOTV
+	writeTestDemo(t, dir, match)
+	broken := filepath.Join(dir, "broken.dem")
+	if err := os.WriteFile(broken, []byte("not a demo"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, report, _ := gatherTestDemos(t, dir, 0)
+	path := filepath.Join(t.TempDir(), "report.json")
+	if err := WriteGatherReport(report, path); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Decode generically to check the field names tools rely on
+	var got struct {
+		Entries []map[string]any `json:"entries"`
+	}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("report is not JSON: %v\n%s", err, data)
+	}
+	if len(got.Entries) != 2 {
+		t.Fatalf("report has %d entries, want 2:\n%s", len(got.Entries), data)
+	}
+	for _, entry := range got.Entries {
+		switch entry["path"] {
+		case broken:
+			if entry["status"] != GatherStatusFailed || entry["error"] == "" || entry["match"] != nil {
+				t.Errorf("broken demo entry = %v, want failed with an error and no match", entry)
+			}
+		case filepath.Join(dir, match.DemoFileName):
+			info, _ := entry["match"].(map[string]any)
+			if entry["status"] != GatherStatusOK || info["mapName"] != "de_mirage" || info["rounds"] != 24.0 ||
+				info["demoKind"] != string(constants.DemoTypeGOTV) || info["demoFileName"] != match.DemoFileName {
+				t.Errorf("demo entry = %v, want ok with the match info", entry)
+			}
+		default:
+			t.Errorf("unexpected entry %v", entry)
+		}
+	}
+
+	if err := WriteGatherReport(nil, path); err == nil {
+		t.Error("writing a nil report succeeded")
+	}
+}
+
+func TestLatestDemosByModTime(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	// Listed alphabetically, which is neither oldest nor newest first.
+	ages := map[string]time.Duration{"a.dem": 2 * time.Hour, "b.dem": 0, "c.dem": 3 * time.Hour, "d.dem": time.Hour}
+	var demos []demoFile
+	for _, name := range []string{"a.dem", "b.dem", "c.dem", "d.dem"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		modTime := now.Add(-ages[name])
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		demos = append(demos, demoFile{path: path, info: info})
+	}
+	demos = append(demos, demoFile{path: filepath.Join(dir, "e.dem"), err: os.ErrNotExist})
+
+	tests := []struct {
+		n    int
+		want []string
+	}{
+		{0, []string{"a.dem", "b.dem", "c.dem", "d.dem", "e.dem"}},
+		{1, []string{"b.dem"}},
+		{3, []string{"b.dem", "d.dem", "a.dem"}},
+		{4, []string{"b.dem", "d.dem", "a.dem", "c.dem"}},
+		{9, []string{"a.dem", "b.dem", "c.dem", "d.dem", "e.dem"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, demo := range latestDemos(demos, tt.n) {
+			got = append(got, filepath.Base(demo.path))
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("latestDemos(%d) = %v, want %v", tt.n, got, tt.want)
+		}
+	}
+	if filepath.Base(demos[0].path) != "a.dem" {
+		t.Error("latestDemos reordered its argument")
+	}
+}
@@ -1,20 +1,132 @@
 package manalyzer
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"maps"
 	"os"
+	"regexp"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
+	"unicode"
 
+	"github.com/akiver/cs-demo-analyzer/pkg/api"
+	"github.com/akiver/cs-demo-analyzer/pkg/api/constants"
 	"github.com/gdamore/tcell/v2"
+	"github.com/gdamore/tcell/v2/terminfo"
 	"github.com/rivo/tview"
 )
 
 const (
 	eventLogHeight = 5
+	actionsHeight  = 9
+
+	confirmResetPage = "confirm-reset"
+
+	basePathLabel   = "Demo Base Path"
+	roundsLabel     = "Rounds (e.g. 1-15)"
+	minPlayersLabel = "Min Players Together"
+	latestLabel     = "Latest Demos (0 = all)"
+	dateFromLabel   = "From (YYYY-MM-DD)"
+	dateToLabel     = "To (YYYY-MM-DD)"
+	demoSourceLabel = "Demo Source"
+	teamLabel       = "Team"
+
+	// noTeamOption is the Team choice that leaves the player fields as typed.
+	noTeamOption = "Custom"
+
+	dateLayout = "2006-01-02"
+
+	// minDuoRounds is the fewest shared rounds for a pair to be named best duo.
+	minDuoRounds = 10
+
+	// trendMatches is how many of the latest matches the title trend shows.
+	trendMatches = 20
 )
 
+// demoSourceOption labels a Preferences.DemoSource value in the form.
+type demoSourceOption struct {
+	label, source string
+}
+
+// demoSourceOptions are the Demo Source choices. Other sources set in
+// config.json are listed by their name.
+var demoSourceOptions = []demoSourceOption{
+	{"Valve", string(constants.DemoSourceValve)},
+	{"FACEIT", string(constants.DemoSourceFaceIt)},
+	{"ESEA", string(constants.DemoSourceESEA)},
+	{"Auto-detect", DemoSourceAuto},
+}
+
+// colorsEnabled is false when NO_COLOR is set or the terminal can't show
+// colors; it is decided once in New.
+var colorsEnabled = true
+
+// colorTagPattern matches tview color tags such as [yellow], [red::b] or [-].
+var colorTagPattern = regexp.MustCompile(`\[([a-zA-Z]+|#[0-9a-fA-F]{6}|-)?(:([a-zA-Z]+|#[0-9a-fA-F]{6}|-)?)?(:[a-zA-Z-]*)?\]`)
+
+// detectColorSupport honors NO_COLOR (https://no-color.org) and falls back
+// to monochrome on terminals whose terminfo reports fewer than 8 colors.
+func detectColorSupport() bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+
+	term := os.Getenv("TERM")
+	if term == "dumb" {
+		return false
+	}
+	if term != "" {
+		if ti, err := terminfo.LookupTerminfo(term); err == nil && ti.Colors < 8 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// applyMonochromeTheme switches tview's default styles to the terminal's
+// default colors.
+func applyMonochromeTheme() {
+	tview.Styles = tview.Theme{
+		PrimitiveBackgroundColor:    tcell.ColorDefault,
+		ContrastBackgroundColor:     tcell.ColorDefault,
+		MoreContrastBackgroundColor: tcell.ColorDefault,
+		BorderColor:                 tcell.ColorDefault,
+		TitleColor:                  tcell.ColorDefault,
+		GraphicsColor:               tcell.ColorDefault,
+		PrimaryTextColor:            tcell.ColorDefault,
+		SecondaryTextColor:          tcell.ColorDefault,
+		TertiaryTextColor:           tcell.ColorDefault,
+		InverseTextColor:            tcell.ColorDefault,
+		ContrastSecondaryTextColor:  tcell.ColorDefault,
+	}
+}
+
+// stripColorTags removes tview color tags from text. The "[]" that
+// tview.Escape inserts is kept, so escaped brackets still show.
+func stripColorTags(text string) string {
+	return colorTagPattern.ReplaceAllStringFunc(text, func(tag string) string {
+		if tag == "[]" {
+			return tag
+		}
+		return ""
+	})
+}
+
+// themeColor returns color, or the terminal default when colors are disabled.
+func themeColor(color tcell.Color) tcell.Color {
+	if !colorsEnabled {
+		return tcell.ColorDefault
+	}
+	return color
+}
+
 // PlayerInput represents user input for player tracking.
 type PlayerInput struct {
 	Name      string
@@ -23,8 +135,25 @@ type PlayerInput struct {
 
 // AnalysisConfig holds configuration for analysis.
 type AnalysisConfig struct {
-	Players  [5]PlayerInput
-	BasePath string
+	Players     [5]PlayerInput
+	BasePath    string
+	Preferences Preferences
+	RoundRange  [2]int // First and last round to count; 0 = unbounded
+
+	// MinPlayersPresent skips matches with fewer of the tracked players;
+	// 0 analyzes every match.
+	MinPlayersPresent int
+
+	// LatestN only parses the N most recently modified demos; 0 parses all.
+	LatestN int
+
+	// DateFrom and DateTo limit the analysis to matches played from the
+	// start of DateFrom through the end of DateTo; zero leaves that end open.
+	DateFrom time.Time
+	DateTo   time.Time
+
+	// DemoSource is chosen in the form and saved to Preferences.DemoSource.
+	DemoSource string
 }
 
 // UI manages the terminal user interface.
@@ -33,8 +162,24 @@ type UI struct {
 	Pages      *tview.Pages
 	Root       *tview.Flex
 	form       *tview.Form
+	actions    *tview.Form
 	eventLog   *EventLog
 	statsTable *StatisticsTable
+	nameFilter *tview.InputField // Filters the statistics table by player name
+	viewSelect *tview.DropDown   // Applies a saved view preset to the statistics table
+	config     *Config
+	results    *resultCache // Aggregated results by input, so Recompute can skip ProcessMatches
+	matches    []*api.Match // Parsed demos from the last analysis, reused by Recompute
+
+	failedDemos int // Demos that failed to parse in the last analysis
+
+	keys keyBindings // Application-wide shortcuts, listed by the F1 help
+
+	// cancelRun stops the running analysis or recompute; nil when idle.
+	// runID tells runs apart, so a finished run can't clear a newer one.
+	runMu     sync.Mutex
+	cancelRun context.CancelFunc
+	runID     int
 }
 
 // EventLog displays timestamped event messages.
@@ -42,6 +187,7 @@ type EventLog struct {
 	textView *tview.TextView
 	maxLines int
 	lines    []string
+	location *time.Location // Zone for timestamps; local time when nil
 }
 
 // StatisticsTable displays player statistics.
@@ -50,6 +196,15 @@ type StatisticsTable struct {
 	data       *WrangleResult
 	filterMap  string
 	filterSide string
+	filterName string            // Lowercased player name substring; empty shows everyone
+	compact    bool              // Only show each player's overall row
+	aliases    map[string]string // SteamID64 -> alias shown instead of the demo name
+	failed     int               // Demos that failed to parse for the shown data
+	sortColumn string            // Leaderboard metric ordering players; empty = name
+	sortDesc   bool
+	focus      string            // SteamID64 of the focused player; others are dimmed
+	rowPlayers map[int]string    // Table row -> SteamID64 of the player shown there
+	matchesOf  string            // SteamID64 whose matches are listed one per row; empty = normal view
 }
 
 func newEventLog(maxLines int) *EventLog {
@@ -60,10 +215,6 @@ func newEventLog(maxLines int) *EventLog {
 	tv.SetBorder(true)
 	tv.SetTitle("Event Log")
 
-	tv.SetChangedFunc(func() {
-		tv.ScrollToEnd()
-	})
-
 	return &EventLog{
 		textView: tv,
 		maxLines: maxLines,
@@ -71,9 +222,26 @@ func newEventLog(maxLines int) *EventLog {
 	}
 }
 
+// SetLocation sets the time zone used for timestamps.
+func (el *EventLog) SetLocation(loc *time.Location) {
+	el.location = loc
+}
+
+// timestamp returns the current time for a log line. Times outside the local
+// zone carry the zone name so shared logs aren't ambiguous.
+func (el *EventLog) timestamp() string {
+	if el.location == nil || el.location == time.Local {
+		return time.Now().Format("15:04:05")
+	}
+	return time.Now().In(el.location).Format("15:04:05 MST")
+}
+
 func (el *EventLog) Log(message string) {
-	timestamp := time.Now().Format("15:04:05")
+	timestamp := el.timestamp()
 	line := fmt.Sprintf("[yellow]%s[-] %s", timestamp, message)
+	if !colorsEnabled {
+		line = stripColorTags(line)
+	}
 
 	el.lines = append(el.lines, line)
 
@@ -90,12 +258,17 @@ func (el *EventLog) Log(message string) {
 			builder.WriteString("\n")
 		}
 	}
-	el.textView.SetText(builder.String())
+	// Scroll here rather than in a changed func, which tview runs on another
+	// goroutine, racing with drawing
+	el.textView.SetText(builder.String()).ScrollToEnd()
 }
 
 func (el *EventLog) LogError(message string) {
-	timestamp := time.Now().Format("15:04:05")
+	timestamp := el.timestamp()
 	line := fmt.Sprintf("[yellow]%s[-] [red]ERROR:[-] %s", timestamp, message)
+	if !colorsEnabled {
+		line = stripColorTags(line)
+	}
 
 	el.lines = append(el.lines, line)
 	if len(el.lines) > el.maxLines {
@@ -110,7 +283,7 @@ func (el *EventLog) LogError(message string) {
 			builder.WriteString("\n")
 		}
 	}
-	el.textView.SetText(builder.String())
+	el.textView.SetText(builder.String()).ScrollToEnd()
 }
 
 
@@ -123,28 +296,55 @@ func newStatisticsTable() *StatisticsTable {
 	table.SetBorder(true)
 	table.SetTitle("Player Statistics")
 
-	return &StatisticsTable{
+	st := &StatisticsTable{
 		table:      table,
 		filterMap:  "",
 		filterSide: "",
 	}
+
+	// Enter on a row focuses that player, or clears the focus if it's theirs.
+	// In the match list it goes back to the normal view.
+	table.SetSelectedFunc(func(row, column int) {
+		if st.matchesOf != "" {
+			st.ShowMatches("")
+			return
+		}
+		if steamID, ok := st.rowPlayers[row]; ok {
+			st.ToggleFocus(steamID)
+		}
+	})
+
+	return st
 }
 
 func (st *StatisticsTable) UpdateData(result *WrangleResult) {
 	st.data = result
+	// A focused player missing from the new data would dim everyone
+	if st.player(st.focus) == nil {
+		st.focus = ""
+	}
+	if st.player(st.matchesOf) == nil {
+		st.matchesOf = ""
+	}
+	st.updateTitle()
 	st.renderTable()
 }
 
 func (st *StatisticsTable) renderTable() {
 	st.table.Clear()
+	st.rowPlayers = make(map[int]string)
 
 	// Header row with column names
-	headers := []string{"Player", "Map", "Side", "KAST%", "ADR", "K/D",
-		"Kills", "Deaths", "FK", "FD", "TK", "TD"}
+	headers := []string{"Player", "Map", "Side", "M", "R", "RW%", "PW%", "KAST%", "ADR", "K/D", "HS%", "+/-",
+		"Kills", "Deaths", "FK", "FD", "TK", "TD", "RWS",
+		"FK W%", "FD W%", "OD W%", "EF/F", "UD", "FA", "1vX", "Aces"}
+	if st.matchesOf != "" {
+		headers[0] = "Match"
+	}
 
 	for col, header := range headers {
 		cell := tview.NewTableCell(header).
-			SetTextColor(tcell.ColorYellow).
+			SetTextColor(themeColor(tcell.ColorYellow)).
 			SetAlign(tview.AlignCenter).
 			SetSelectable(false).
 			SetAttributes(tcell.AttrBold)
@@ -153,6 +353,10 @@ func (st *StatisticsTable) renderTable() {
 
 	// Data rows
 	row := 1
+	if playerStats := st.player(st.matchesOf); playerStats != nil {
+		st.addMatchRows(row, playerStats)
+		return
+	}
 	if st.data != nil {
 		// Sort by player name initially
 		sortedPlayers := make([]*PlayerStats, 0, len(st.data.PlayerStats))
@@ -166,11 +370,21 @@ func (st *StatisticsTable) renderTable() {
 			if sortedPlayers[i] == nil || sortedPlayers[j] == nil {
 				return false
 			}
-			return sortedPlayers[i].PlayerName < sortedPlayers[j].PlayerName
+			return st.less(sortedPlayers[i], sortedPlayers[j])
 		})
 
 		for _, playerStats := range sortedPlayers {
-			if playerStats == nil {
+			if playerStats == nil || !st.matchesName(playerStats) {
+				continue
+			}
+			firstRow := row
+
+			if st.compact {
+				if playerStats.OverallStats != nil {
+					st.addOverallRow(row, st.displayName(playerStats), playerStats.OverallStats)
+					row++
+				}
+				st.markPlayerRows(firstRow, row, playerStats.SteamID64)
 				continue
 			}
 			
@@ -188,23 +402,29 @@ func (st *StatisticsTable) renderTable() {
 					}
 
 					if sideStats, ok := mapStats.SideStats[side]; ok {
-						st.addDataRow(row, playerStats.PlayerName, mapName, side, sideStats)
+						st.addDataRow(row, st.displayName(playerStats), mapName, side, sideStats)
 						row++
 					}
 				}
 				
 				// Add per-map summary row (T+CT combined) if not filtering by side
 				if st.filterSide == "" {
-					st.addMapSummaryRow(row, playerStats.PlayerName, mapName, mapStats)
+					st.addMapSummaryRow(row, st.displayName(playerStats), mapName, mapStats)
 					row++
 				}
 			}
 
 			// Add overall row
 			if st.filterMap == "" && st.filterSide == "" && playerStats.OverallStats != nil {
-				st.addOverallRow(row, playerStats.PlayerName, playerStats.OverallStats)
+				st.addOverallRow(row, st.displayName(playerStats), playerStats.OverallStats)
 				row++
 			}
+			st.markPlayerRows(firstRow, row, playerStats.SteamID64)
+		}
+
+		// Team row combining every player, like their own overall rows
+		if st.data.TeamStats != nil && st.filterName == "" && (st.compact || st.filterMap == "" && st.filterSide == "") {
+			st.addOverallRow(row, "Team", st.data.TeamStats)
 		}
 	}
 }
@@ -219,21 +439,36 @@ func (st *StatisticsTable) addDataRow(row int, playerName, mapName, side string,
 		playerName,
 		mapName,
 		side,
+		"-",
+		fmt.Sprintf("%d", stats.RoundsPlayed),
+		fmt.Sprintf("%.1f", stats.RoundWinRate),
+		pistolRoundText(stats.PistolRoundsWon, stats.PistolRoundsPlayed),
 		fmt.Sprintf("%.1f", stats.KAST),
 		fmt.Sprintf("%.1f", stats.ADR),
 		fmt.Sprintf("%.2f", stats.KD),
+		fmt.Sprintf("%.1f", percentage(stats.Headshots, stats.Kills)),
+		fmt.Sprintf("%+.2f", stats.KillDeathDiffPerRound),
 		fmt.Sprintf("%d", stats.Kills),
 		fmt.Sprintf("%d", stats.Deaths),
 		fmt.Sprintf("%d", stats.FirstKills),
 		fmt.Sprintf("%d", stats.FirstDeaths),
 		fmt.Sprintf("%d", stats.TradeKills),
 		fmt.Sprintf("%d", stats.TradeDeaths),
+		fmt.Sprintf("%.1f", stats.RWS),
+		fmt.Sprintf("%.1f", percentage(stats.FirstKillRoundsWon, stats.FirstKills)),
+		fmt.Sprintf("%.1f", percentage(stats.FirstDeathRoundsWon, stats.FirstDeaths)),
+		openingDuelText(stats.FirstKills, stats.FirstDeaths),
+		fmt.Sprintf("%.2f", stats.FlashEfficiency),
+		fmt.Sprintf("%d", stats.UtilityDamage),
+		fmt.Sprintf("%d", stats.FlashAssists),
+		clutchText(sum(stats.ClutchWins[:]), sum(stats.ClutchAttempts[:])),
+		fmt.Sprintf("%d", stats.Kills5),
 	}
 
 	for col, text := range cols {
 		cell := tview.NewTableCell(text).
 			SetAlign(tview.AlignCenter).
-			SetTextColor(tcell.ColorWhite)
+			SetTextColor(themeColor(tcell.ColorWhite))
 		st.table.SetCell(row, col, cell)
 	}
 }
@@ -249,10 +484,18 @@ func (st *StatisticsTable) addMapSummaryRow(row int, playerName, mapName string,
 	var totalFirstKills, totalFirstDeaths int
 	var totalTradeKills, totalTradeDeaths int
 	var totalHeadshots, totalRoundsPlayed int
-	var weightedKAST, weightedADR float64
+	var totalFirstKillRoundsWon, totalFirstDeathRoundsWon int
+	var totalFlashesThrown, totalEnemiesFlashed int
+	var totalUtilityDamage, totalFlashAssists int
+	var totalClutchWins, totalClutchAttempts int
+	var totalAces int
+	var totalRoundsWon, totalPistolRoundsWon, totalPistolRoundsPlayed int
+	var weightedKAST, weightedADR, weightedRWS float64
+	var adrRounds int // Rounds on sides with damage data
 	
 	for _, sideStats := range mapStats.SideStats {
-		if sideStats == nil {
+		// A side the player never played on this map has no data
+		if sideStats == nil || sideStats.RoundsPlayed == 0 {
 			continue
 		}
 		totalKills += sideStats.Kills
@@ -264,18 +507,36 @@ func (st *StatisticsTable) addMapSummaryRow(row int, playerName, mapName string,
 		totalTradeDeaths += sideStats.TradeDeaths
 		totalHeadshots += sideStats.Headshots
 		totalRoundsPlayed += sideStats.RoundsPlayed
+		totalFirstKillRoundsWon += sideStats.FirstKillRoundsWon
+		totalFirstDeathRoundsWon += sideStats.FirstDeathRoundsWon
+		totalFlashesThrown += sideStats.FlashesThrown
+		totalEnemiesFlashed += sideStats.EnemiesFlashed
+		totalUtilityDamage += sideStats.UtilityDamage
+		totalFlashAssists += sideStats.FlashAssists
+		totalClutchWins += sum(sideStats.ClutchWins[:])
+		totalClutchAttempts += sum(sideStats.ClutchAttempts[:])
+		totalAces += sideStats.Kills5
+		totalRoundsWon += sideStats.RoundsWon
+		totalPistolRoundsWon += sideStats.PistolRoundsWon
+		totalPistolRoundsPlayed += sideStats.PistolRoundsPlayed
 		
 		// Weighted average for KAST and ADR
 		weightedKAST += (sideStats.KAST / 100.0) * float64(sideStats.RoundsPlayed)
-		weightedADR += sideStats.ADR * float64(sideStats.RoundsPlayed)
+		weightedADR += sideStats.ADR * float64(sideStats.DamageRounds)
+		adrRounds += sideStats.DamageRounds
+		weightedRWS += sideStats.RWS * float64(sideStats.RoundsPlayed)
 	}
 	
 	// Calculate averages
 	kast := 0.0
 	adr := 0.0
+	rws := 0.0
 	if totalRoundsPlayed > 0 {
 		kast = (weightedKAST / float64(totalRoundsPlayed)) * 100.0
-		adr = weightedADR / float64(totalRoundsPlayed)
+		rws = weightedRWS / float64(totalRoundsPlayed)
+	}
+	if adrRounds > 0 {
+		adr = weightedADR / float64(adrRounds)
 	}
 	
 	// Calculate K/D
@@ -290,21 +551,36 @@ func (st *StatisticsTable) addMapSummaryRow(row int, playerName, mapName string,
 		playerName,
 		mapName,
 		"Both",
+		fmt.Sprintf("%d", mapStats.MatchesPlayed),
+		fmt.Sprintf("%d", totalRoundsPlayed),
+		fmt.Sprintf("%.1f", percentage(totalRoundsWon, totalRoundsPlayed)),
+		pistolRoundText(totalPistolRoundsWon, totalPistolRoundsPlayed),
 		fmt.Sprintf("%.1f", kast),
 		fmt.Sprintf("%.1f", adr),
 		fmt.Sprintf("%.2f", kd),
+		fmt.Sprintf("%.1f", percentage(totalHeadshots, totalKills)),
+		fmt.Sprintf("%+.2f", killDeathDiffPerRound(totalKills, totalDeaths, totalRoundsPlayed)),
 		fmt.Sprintf("%d", totalKills),
 		fmt.Sprintf("%d", totalDeaths),
 		fmt.Sprintf("%d", totalFirstKills),
 		fmt.Sprintf("%d", totalFirstDeaths),
 		fmt.Sprintf("%d", totalTradeKills),
 		fmt.Sprintf("%d", totalTradeDeaths),
+		fmt.Sprintf("%.1f", rws),
+		fmt.Sprintf("%.1f", percentage(totalFirstKillRoundsWon, totalFirstKills)),
+		fmt.Sprintf("%.1f", percentage(totalFirstDeathRoundsWon, totalFirstDeaths)),
+		openingDuelText(totalFirstKills, totalFirstDeaths),
+		fmt.Sprintf("%.2f", flashEfficiency(totalEnemiesFlashed, totalFlashesThrown)),
+		fmt.Sprintf("%d", totalUtilityDamage),
+		fmt.Sprintf("%d", totalFlashAssists),
+		clutchText(totalClutchWins, totalClutchAttempts),
+		fmt.Sprintf("%d", totalAces),
 	}
 
 	for col, text := range cols {
 		cell := tview.NewTableCell(text).
 			SetAlign(tview.AlignCenter).
-			SetTextColor(tcell.ColorAqua).
+			SetTextColor(themeColor(tcell.ColorAqua)).
 			SetAttributes(tcell.AttrBold)
 		st.table.SetCell(row, col, cell)
 	}
@@ -315,36 +591,329 @@ func (st *StatisticsTable) addOverallRow(row int, playerName string, stats *Over
 		return
 	}
 	
-	cols := []string{
-		playerName,
-		"Overall",
-		"All",
+	cols := append([]string{playerName, "Overall", "All"}, overallStatCols(stats)...)
+
+	for col, text := range cols {
+		cell := tview.NewTableCell(text).
+			SetAlign(tview.AlignCenter).
+			SetTextColor(themeColor(tcell.ColorGreen)).
+			SetAttributes(tcell.AttrBold)
+		st.table.SetCell(row, col, cell)
+	}
+}
+
+// overallStatCols formats stats for the columns after Player, Map and Side.
+func overallStatCols(stats *OverallStatistics) []string {
+	return []string{
+		fmt.Sprintf("%d", stats.MatchesPlayed),
+		fmt.Sprintf("%d", stats.RoundsPlayed),
+		fmt.Sprintf("%.1f", stats.RoundWinRate),
+		pistolRoundText(stats.PistolRoundsWon, stats.PistolRoundsPlayed),
 		fmt.Sprintf("%.1f", stats.KAST),
 		fmt.Sprintf("%.1f", stats.ADR),
 		fmt.Sprintf("%.2f", stats.KD),
+		fmt.Sprintf("%.1f", percentage(stats.Headshots, stats.Kills)),
+		fmt.Sprintf("%+.2f", stats.KillDeathDiffPerRound),
 		fmt.Sprintf("%d", stats.Kills),
 		fmt.Sprintf("%d", stats.Deaths),
 		fmt.Sprintf("%d", stats.FirstKills),
 		fmt.Sprintf("%d", stats.FirstDeaths),
 		fmt.Sprintf("%d", stats.TradeKills),
 		fmt.Sprintf("%d", stats.TradeDeaths),
+		fmt.Sprintf("%.1f", stats.RWS),
+		fmt.Sprintf("%.1f", stats.OpeningKillRoundWinRate),
+		fmt.Sprintf("%.1f", stats.OpeningDeathRoundWinRate),
+		openingDuelText(stats.FirstKills, stats.FirstDeaths),
+		fmt.Sprintf("%.2f", stats.FlashEfficiency),
+		fmt.Sprintf("%d", stats.UtilityDamage),
+		fmt.Sprintf("%d", stats.FlashAssists),
+		clutchText(stats.ClutchWins, stats.ClutchAttempts),
+		fmt.Sprintf("%d", stats.Kills5),
 	}
+}
 
-	for col, text := range cols {
-		cell := tview.NewTableCell(text).
-			SetAlign(tview.AlignCenter).
-			SetTextColor(tcell.ColorGreen).
-			SetAttributes(tcell.AttrBold)
-		st.table.SetCell(row, col, cell)
+// addMatchRows lists playerStats' matches, oldest first, labelled by demo
+// file. With a side filter each row shows that side only.
+func (st *StatisticsTable) addMatchRows(row int, playerStats *PlayerStats) {
+	for _, matchStats := range st.shownMatches(playerStats) {
+		if st.filterSide != "" {
+			if sideStats := matchStats.SideStats[st.filterSide]; sideStats != nil && sideStats.RoundsPlayed > 0 {
+				st.addDataRow(row, matchStats.DemoFileName, matchStats.MapName, st.filterSide, sideStats)
+				row++
+			}
+			continue
+		}
+
+		cols := append([]string{matchStats.DemoFileName, matchStats.MapName, "Both"}, overallStatCols(matchStats.Overall())...)
+		for col, text := range cols {
+			cell := tview.NewTableCell(text).
+				SetAlign(tview.AlignCenter).
+				SetTextColor(themeColor(tcell.ColorWhite))
+			st.table.SetCell(row, col, cell)
+		}
+		row++
+	}
+}
+
+// shownMatches returns playerStats' matches on the filtered map, oldest
+// first.
+func (st *StatisticsTable) shownMatches(playerStats *PlayerStats) []*MatchStatistics {
+	var matches []*MatchStatistics
+	for mapName, mapStats := range playerStats.MapStats {
+		if st.filterMap == "" || mapName == st.filterMap {
+			matches = append(matches, mapStats.Matches...)
+		}
+	}
+	sortMatchesByDate(matches)
+	return matches
+}
+
+// trendText draws the KAST and ADR of playerStats' latest shown matches as
+// sparklines, oldest first, or returns "" for fewer than two matches.
+func (st *StatisticsTable) trendText(playerStats *PlayerStats) string {
+	var kast, adr []float64
+	for _, matchStats := range st.shownMatches(playerStats) {
+		if st.filterSide != "" {
+			if sideStats := matchStats.SideStats[st.filterSide]; sideStats != nil && sideStats.RoundsPlayed > 0 {
+				kast = append(kast, sideStats.KAST)
+				adr = append(adr, sideStats.ADR)
+			}
+			continue
+		}
+		overall := matchStats.Overall()
+		kast = append(kast, overall.KAST)
+		adr = append(adr, overall.ADR)
+	}
+	if len(kast) < 2 {
+		return ""
+	}
+	if len(kast) > trendMatches {
+		kast, adr = kast[len(kast)-trendMatches:], adr[len(adr)-trendMatches:]
+	}
+	return "KAST " + sparkline(kast) + " ADR " + sparkline(adr)
+}
+
+// sparkline draws values as block characters scaled between their minimum
+// and maximum.
+func sparkline(values []float64) string {
+	const blocks = "▁▂▃▄▅▆▇█"
+	levels := []rune(blocks)
+	lo, hi := slices.Min(values), slices.Max(values)
+
+	var b strings.Builder
+	for _, value := range values {
+		level := 0
+		if hi > lo {
+			level = int((value - lo) / (hi - lo) * float64(len(levels)-1))
+		}
+		b.WriteRune(levels[level])
+	}
+	return b.String()
+}
+
+// openingDuelText formats the opening duel win rate, or "-" when the player
+// took no opening duels.
+func openingDuelText(firstKills, firstDeaths int) string {
+	if firstKills+firstDeaths == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f", percentage(firstKills, firstKills+firstDeaths))
+}
+
+// pistolRoundText formats the pistol round win rate, or "-" when no pistol
+// rounds were played.
+func pistolRoundText(won, played int) string {
+	if played == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f", percentage(won, played))
+}
+
+// clutchText formats clutches as wins/attempts, or "-" when there were none.
+func clutchText(wins, attempts int) string {
+	if attempts == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d/%d", wins, attempts)
+}
+
+// SetAliases sets the display aliases, keyed by SteamID64, and redraws.
+func (st *StatisticsTable) SetAliases(aliases map[string]string) {
+	st.aliases = aliases
+	st.renderTable()
+}
+
+// displayName returns the player's alias if one is configured, else the name
+// from the demos.
+func (st *StatisticsTable) displayName(playerStats *PlayerStats) string {
+	if alias := st.aliases[playerStats.SteamID64]; alias != "" {
+		return alias
+	}
+	return playerStats.ShownName()
+}
+
+// SetCompact switches between the detailed view (map × side rows) and the
+// compact view with a single overall row per player.
+func (st *StatisticsTable) SetCompact(compact bool) {
+	st.compact = compact
+	st.updateTitle()
+	st.renderTable()
+}
+
+// SetFailedDemos flags the shown stats as partial when failed > 0; pass 0
+// after a clean run to clear the warning.
+func (st *StatisticsTable) SetFailedDemos(failed int) {
+	st.failed = failed
+	st.updateTitle()
+}
+
+func (st *StatisticsTable) updateTitle() {
+	title := "Player Statistics"
+	if playerStats := st.player(st.matchesOf); playerStats != nil {
+		title += " - matches of " + tview.Escape(st.displayName(playerStats))
+		if trend := st.trendText(playerStats); trend != "" {
+			title += " (" + trend + ")"
+		}
+	} else if st.compact {
+		title += " (compact)"
+	}
+	if st.filterMap != "" || st.filterSide != "" {
+		mapFilter, sideFilter := st.filterMap, st.filterSide
+		if mapFilter == "" {
+			mapFilter = "all maps"
+		}
+		if sideFilter == "" {
+			sideFilter = "both sides"
+		}
+		title += " " + tview.Escape("["+mapFilter+" / "+sideFilter+"]")
+	}
+	if st.failed > 0 {
+		title += fmt.Sprintf(" [red::b](PARTIAL: %d demos failed)[-::-]", st.failed)
+	}
+	if !colorsEnabled {
+		title = stripColorTags(title)
+	}
+	st.table.SetTitle(title)
+}
+
+func (st *StatisticsTable) ToggleCompact() {
+	st.SetCompact(!st.compact)
+}
+
+// ShowMatches lists steamID's matches one per row instead of the per-map
+// stats; empty returns to the normal view.
+func (st *StatisticsTable) ShowMatches(steamID string) {
+	st.matchesOf = steamID
+	st.updateTitle()
+	st.renderTable()
+	st.table.ScrollToBeginning()
+}
+
+// ToggleMatches lists the matches of the focused player, or else of the
+// player on the selected row, or returns to the normal view.
+func (st *StatisticsTable) ToggleMatches() {
+	if st.matchesOf != "" {
+		st.ShowMatches("")
+		return
+	}
+	steamID := st.focus
+	if steamID == "" {
+		row, _ := st.table.GetSelection()
+		steamID = st.rowPlayers[row]
+	}
+	if steamID != "" {
+		st.ShowMatches(steamID)
+	}
+}
+
+// player returns the shown stats of steamID, or nil.
+func (st *StatisticsTable) player(steamID string) *PlayerStats {
+	if st.data == nil || steamID == "" {
+		return nil
+	}
+	for _, playerStats := range st.data.PlayerStats {
+		if playerStats != nil && playerStats.SteamID64 == steamID {
+			return playerStats
+		}
 	}
+	return nil
 }
 
 func (st *StatisticsTable) SetFilter(mapFilter, sideFilter string) {
 	st.filterMap = mapFilter
 	st.filterSide = sideFilter
+	st.updateTitle()
+	st.renderTable()
+}
+
+// CycleMapFilter moves the map filter to the next map of the shown results
+// in alphabetical order, and from the last one back to every map.
+func (st *StatisticsTable) CycleMapFilter() {
+	var maps []string
+	if st.data != nil {
+		maps = slices.Sorted(slices.Values(st.data.MapList))
+	}
+	next := ""
+	if i := slices.Index(maps, st.filterMap); i+1 < len(maps) {
+		next = maps[i+1]
+	}
+	st.SetFilter(next, st.filterSide)
+}
+
+// CycleSideFilter moves the side filter from both sides to T, CT and back.
+func (st *StatisticsTable) CycleSideFilter() {
+	next := map[string]string{"": "T", "T": "CT", "CT": ""}[st.filterSide]
+	st.SetFilter(st.filterMap, next)
+}
+
+// SetNameFilter only shows players whose name or alias contains query,
+// ignoring case; empty shows everyone.
+func (st *StatisticsTable) SetNameFilter(query string) {
+	st.filterName = strings.ToLower(strings.TrimSpace(query))
+	st.renderTable()
+}
+
+// matchesName reports whether playerStats passes the name filter.
+func (st *StatisticsTable) matchesName(playerStats *PlayerStats) bool {
+	return st.filterName == "" ||
+		strings.Contains(strings.ToLower(playerStats.PlayerName), st.filterName) ||
+		strings.Contains(strings.ToLower(st.displayName(playerStats)), st.filterName)
+}
+
+// ToggleFocus highlights steamID's rows by dimming every other player's, or
+// restores normal rendering if steamID is already focused.
+func (st *StatisticsTable) ToggleFocus(steamID string) {
+	if st.focus == steamID {
+		steamID = ""
+	}
+	st.SetFocus(steamID)
+}
+
+// SetFocus dims the rows of every player but steamID; empty clears the focus.
+func (st *StatisticsTable) SetFocus(steamID string) {
+	st.focus = steamID
 	st.renderTable()
 }
 
+// markPlayerRows records rows [from, to) as steamID's and dims them when
+// another player is focused.
+func (st *StatisticsTable) markPlayerRows(from, to int, steamID string) {
+	for row := from; row < to; row++ {
+		st.rowPlayers[row] = steamID
+		if st.focus == "" || st.focus == steamID {
+			continue
+		}
+		for col := 0; col < st.table.GetColumnCount(); col++ {
+			if cell := st.table.GetCell(row, col); cell != nil {
+				cell.SetTextColor(themeColor(tcell.ColorGray)).SetAttributes(tcell.AttrDim)
+			}
+		}
+	}
+}
+
+// playerNameLabel and playerSteamLabel label the inputs of player i (0-based).
+func playerNameLabel(i int) string  { return fmt.Sprintf("Player %d Name", i+1) }
+func playerSteamLabel(i int) string { return fmt.Sprintf("Player %d SteamID64", i+1) }
 
 func createPlayerInputForm() *tview.Form {
 	form := tview.NewForm()
@@ -353,54 +922,223 @@ func createPlayerInputForm() *tview.Form {
 	form.SetTitle("Player Configuration")
 	form.SetTitleAlign(tview.AlignLeft)
 
-	// Add 5 player input pairs
-	for i := 1; i <= 5; i++ {
-		playerLabel := fmt.Sprintf("Player %d Name", i)
-		steamLabel := fmt.Sprintf("Player %d SteamID64", i)
+	// Teams are added by applyConfigToForm
+	form.AddDropDown(teamLabel, []string{noTeamOption}, 0, nil)
 
-		form.AddInputField(playerLabel, "", 30, nil, nil)
-		form.AddInputField(steamLabel, "", 17, validateSteamID64, nil)
+	// Add 5 player input pairs
+	for i := 0; i < 5; i++ {
+		form.AddInputField(playerNameLabel(i), "", 30, nil, nil)
+		form.AddInputField(playerSteamLabel(i), "", 17, validateSteamIDInput, nil)
 	}
 
 	// Add base path input
-	form.AddInputField("Demo Base Path", "", 50, nil, nil)
+	form.AddInputField(basePathLabel, "", 50, nil, nil)
+	form.AddInputField(roundsLabel, "", 7, validateRoundRange, nil)
+	form.AddInputField(minPlayersLabel, "", 2, tview.InputFieldInteger, nil)
+	form.AddInputField(latestLabel, "", 4, tview.InputFieldInteger, nil)
+	form.AddInputField(dateFromLabel, "", 10, validateDate, nil)
+	form.AddInputField(dateToLabel, "", 10, validateDate, nil)
+	labels := make([]string, len(demoSourceOptions))
+	for i, option := range demoSourceOptions {
+		labels[i] = option.label
+	}
+	form.AddDropDown(demoSourceLabel, labels, 0, nil)
 
 	// Add buttons
 	form.AddButton("Analyze", nil) // Handler added later
+	form.AddButton("Cancel", nil)
 	form.AddButton("Clear", nil)
+	form.AddButton("Browse", nil)
+
+	return form
+}
+
+// createActionsForm holds secondary actions. It is horizontal so buttons
+// wrap onto new lines instead of being cut off in the narrow left column.
+func createActionsForm() *tview.Form {
+	form := tview.NewForm().SetHorizontal(true)
+
+	form.SetBorder(true)
+	form.SetTitle("Actions")
+	form.SetTitleAlign(tview.AlignLeft)
+
+	form.AddButton("Recompute", nil) // Handlers added in setupActionHandlers
+	form.AddButton("Save Results", nil)
+	form.AddButton("Load Results", nil)
+	form.AddButton("Import CSV", nil)
+	form.AddButton("Export CSV", nil)
+	form.AddButton("Export JSON", nil)
+	form.AddButton("Open Log", nil)
+	form.AddButton("Charts", nil)
+	form.AddButton("Reset Config", nil)
 
 	return form
 }
 
-// validateSteamID64 ensures only numeric input for SteamID64
-func validateSteamID64(text string, lastChar rune) bool {
-	// Allow empty string or only digits
+// validateSteamIDInput accepts anything ResolveSteamID might take (SteamID64,
+// SteamID2, SteamID3 or a profile URL), only rejecting whitespace.
+func validateSteamIDInput(text string, lastChar rune) bool {
+	return !unicode.IsSpace(lastChar)
+}
+
+// validateRoundRange allows only digits and a single dash while typing.
+func validateRoundRange(text string, lastChar rune) bool {
+	if lastChar == '-' {
+		return strings.Count(text, "-") == 1
+	}
+	return lastChar >= '0' && lastChar <= '9'
+}
+
+// parseRoundRange parses "a-b", "a-", "-b" or "a" into a RoundRange. Empty
+// text means all rounds.
+func parseRoundRange(text string) ([2]int, error) {
+	var roundRange [2]int
+	text = strings.TrimSpace(text)
 	if text == "" {
-		return true
+		return roundRange, nil
+	}
+
+	from, to, isRange := strings.Cut(text, "-")
+	if !isRange {
+		to = from
+	}
+	for i, part := range []string{from, to} {
+		if part == "" {
+			continue
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 1 {
+			return roundRange, fmt.Errorf("invalid round range %q", text)
+		}
+		roundRange[i] = n
 	}
-	// Check if character is a digit
-	if lastChar < '0' || lastChar > '9' {
+	if roundRange[0] > 0 && roundRange[1] > 0 && roundRange[0] > roundRange[1] {
+		return roundRange, fmt.Errorf("invalid round range %q: start is after end", text)
+	}
+	return roundRange, nil
+}
+
+// validateDate accepts digits and the two dashes of a YYYY-MM-DD date while
+// typing.
+func validateDate(text string, lastChar rune) bool {
+	if len(text) > len(dateLayout) {
 		return false
 	}
-	// Limit to 17 characters (SteamID64 length)
-	return len(text) <= 17
+	if lastChar == '-' {
+		return strings.Count(text, "-") <= 2
+	}
+	return lastChar >= '0' && lastChar <= '9'
+}
+
+// parseDateRange parses the From and To fields as YYYY-MM-DD dates in loc.
+// Either may be empty to leave that end open.
+func parseDateRange(fromText, toText string, loc *time.Location) (from, to time.Time, err error) {
+	if text := strings.TrimSpace(fromText); text != "" {
+		if from, err = time.ParseInLocation(dateLayout, text, loc); err != nil {
+			return from, to, fmt.Errorf("invalid From date %q, want YYYY-MM-DD", text)
+		}
+	}
+	if text := strings.TrimSpace(toText); text != "" {
+		if to, err = time.ParseInLocation(dateLayout, text, loc); err != nil {
+			return from, to, fmt.Errorf("invalid To date %q, want YYYY-MM-DD", text)
+		}
+	}
+	if !from.IsZero() && !to.IsZero() && from.After(to) {
+		return from, to, fmt.Errorf("invalid date range: %s is after %s", fromText, toText)
+	}
+	return from, to, nil
+}
+
+// dateRangeFromForm reads and validates the From and To fields.
+func dateRangeFromForm(form *tview.Form, loc *time.Location) (from, to time.Time, err error) {
+	var fromText, toText string
+	if field, ok := form.GetFormItemByLabel(dateFromLabel).(*tview.InputField); ok {
+		fromText = field.GetText()
+	}
+	if field, ok := form.GetFormItemByLabel(dateToLabel).(*tview.InputField); ok {
+		toText = field.GetText()
+	}
+	return parseDateRange(fromText, toText, loc)
 }
 
+// roundRangeFromForm reads and validates the rounds field.
+func roundRangeFromForm(form *tview.Form) ([2]int, error) {
+	field, ok := form.GetFormItemByLabel(roundsLabel).(*tview.InputField)
+	if !ok {
+		return [2]int{}, nil
+	}
+	return parseRoundRange(field.GetText())
+}
 
 func (u *UI) setupFormHandlers(form *tview.Form) {
-	// Get button indices (assuming Analyze=0, Clear=1)
-	analyzeIdx := form.GetButtonCount() - 2
-	clearIdx := form.GetButtonCount() - 1
+	analyzeIdx := form.GetButtonIndex("Analyze")
+	clearIdx := form.GetButtonIndex("Clear")
 
 	// Set Analyze button handler
 	form.GetButton(analyzeIdx).SetSelectedFunc(func() {
 		u.onAnalyzeClicked(form)
 	})
 
+	form.GetButton(form.GetButtonIndex("Cancel")).SetSelectedFunc(u.onCancelClicked)
+
 	// Set Clear button handler
 	form.GetButton(clearIdx).SetSelectedFunc(func() {
 		u.onClearClicked(form)
 	})
+
+	form.GetButton(form.GetButtonIndex("Browse")).SetSelectedFunc(func() {
+		u.onBrowseClicked(form)
+	})
+
+	// Tab past the last button moves to the actions panel
+	form.SetInputCapture(u.tabToNext(form, func() tview.Primitive { return u.actions }))
+}
+
+func (u *UI) setupActionHandlers(actions *tview.Form) {
+	actions.GetButton(actions.GetButtonIndex("Recompute")).SetSelectedFunc(func() {
+		u.onRecomputeClicked(u.form)
+	})
+	actions.GetButton(actions.GetButtonIndex("Save Results")).SetSelectedFunc(func() {
+		u.onSaveResultsClicked()
+	})
+	actions.GetButton(actions.GetButtonIndex("Load Results")).SetSelectedFunc(func() {
+		u.onLoadResultsClicked()
+	})
+	actions.GetButton(actions.GetButtonIndex("Import CSV")).SetSelectedFunc(func() {
+		u.onImportCSVClicked()
+	})
+	actions.GetButton(actions.GetButtonIndex("Export CSV")).SetSelectedFunc(func() {
+		u.onExportCSVClicked()
+	})
+	actions.GetButton(actions.GetButtonIndex("Export JSON")).SetSelectedFunc(func() {
+		u.onExportJSONClicked()
+	})
+	actions.GetButton(actions.GetButtonIndex("Open Log")).SetSelectedFunc(func() {
+		u.onOpenLogClicked()
+	})
+	actions.GetButton(actions.GetButtonIndex("Charts")).SetSelectedFunc(func() {
+		u.onExportChartsClicked()
+	})
+	actions.GetButton(actions.GetButtonIndex("Reset Config")).SetSelectedFunc(func() {
+		u.onResetConfigClicked()
+	})
+
+	actions.SetInputCapture(u.tabToNext(actions, func() tview.Primitive { return u.form }))
+}
+
+// tabToNext returns an input capture that moves focus to next() when Tab is
+// pressed on form's last button, instead of wrapping around within form.
+func (u *UI) tabToNext(form *tview.Form, next func() tview.Primitive) func(*tcell.EventKey) *tcell.EventKey {
+	return func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() != tcell.KeyTab {
+			return event
+		}
+		if _, button := form.GetFocusedItemIndex(); button == form.GetButtonCount()-1 {
+			u.App.SetFocus(next())
+			return nil
+		}
+		return event
+	}
 }
 
 func (u *UI) onAnalyzeClicked(form *tview.Form) {
@@ -431,8 +1169,200 @@ func (u *UI) onAnalyzeClicked(form *tview.Form) {
 		return
 	}
 
+	roundRange, err := roundRangeFromForm(form)
+	if err != nil {
+		u.logEvent(fmt.Sprintf("Error: %v", err))
+		return
+	}
+	config.RoundRange = roundRange
+
+	config.DateFrom, config.DateTo, err = dateRangeFromForm(form, u.config.Preferences.Location())
+	if err != nil {
+		u.logEvent(fmt.Sprintf("Error: %v", err))
+		return
+	}
+
+	u.saveConfig(config)
+	config.Preferences = u.config.Preferences
+
 	// Start analysis (in goroutine to keep UI responsive)
-	go u.runAnalysis(config)
+	apiKey := u.config.SteamAPIKey
+	go func() {
+		if u.resolvePlayers(&config, apiKey) {
+			u.runAnalysis(config)
+		}
+	}()
+}
+
+// onRecomputeClicked re-aggregates the last parsed demos with the current
+// players and preferences, without parsing the demos again.
+func (u *UI) onRecomputeClicked(form *tview.Form) {
+	if len(u.matches) == 0 {
+		u.logEvent("Error: Nothing to recompute, run Analyze first")
+		return
+	}
+
+	// Pick up preference edits made to config.json since startup
+	if cfg, err := LoadConfig(); err == nil {
+		u.config.Preferences = cfg.Preferences
+		u.eventLog.SetLocation(cfg.Preferences.Location())
+	} else {
+		u.logEvent(fmt.Sprintf("Warning: could not reload preferences: %v", err))
+	}
+
+	config := u.extractConfigFromForm(form)
+	config.Preferences = u.config.Preferences
+	roundRange, err := roundRangeFromForm(form)
+	if err != nil {
+		u.logEvent(fmt.Sprintf("Error: %v", err))
+		return
+	}
+	config.RoundRange = roundRange
+
+	config.DateFrom, config.DateTo, err = dateRangeFromForm(form, config.Preferences.Location())
+	if err != nil {
+		u.logEvent(fmt.Sprintf("Error: %v", err))
+		return
+	}
+
+	matches, failedDemos, apiKey := u.matches, u.failedDemos, u.config.SteamAPIKey
+	go func() {
+		if u.resolvePlayers(&config, apiKey) {
+			u.runRecompute(matches, failedDemos, config)
+		}
+	}()
+}
+
+func (u *UI) onSaveResultsClicked() {
+	result := u.statsTable.data
+	if result == nil {
+		u.logEvent("Error: No results to save, run Analyze first")
+		return
+	}
+
+	path, err := defaultResultPath()
+	if err != nil {
+		u.logEvent(fmt.Sprintf("Error: %v", err))
+		return
+	}
+
+	location := u.config.Preferences.Location()
+	go func() {
+		if err := SaveResult(result, path, location); err != nil {
+			u.logEvent(fmt.Sprintf("Error saving results: %v", err))
+			return
+		}
+		u.logEvent(fmt.Sprintf("Results saved to %s", path))
+	}()
+}
+
+func (u *UI) onLoadResultsClicked() {
+	path, err := defaultResultPath()
+	if err != nil {
+		u.logEvent(fmt.Sprintf("Error: %v", err))
+		return
+	}
+
+	go func() {
+		result, err := LoadResult(path)
+		if err != nil {
+			u.logEvent(fmt.Sprintf("Error loading results: %v", err))
+			return
+		}
+		u.logEvent(fmt.Sprintf("Loaded results for %d players from %s", len(result.PlayerStats), path))
+		u.QueueUpdate(func() {
+			u.statsTable.SetFailedDemos(0)
+			u.statsTable.UpdateData(result)
+		})
+	}()
+}
+
+// onImportCSVClicked asks for a CSV, e.g. one shared by a teammate, and
+// shows its results without needing the demos. The picker starts in the
+// config directory, where Export CSV writes.
+func (u *UI) onImportCSVClicked() {
+	start, _ := configDir()
+	u.showFileBrowser("Choose CSV to Import", start, ".csv", func(path string) {
+		go func() {
+			f, err := os.Open(path)
+			if err != nil {
+				u.logEvent(fmt.Sprintf("Error importing CSV: %v", err))
+				return
+			}
+			defer f.Close()
+
+			result, err := ImportCSV(f)
+			if result == nil {
+				u.logEvent(fmt.Sprintf("Error importing CSV: %v", err))
+				return
+			}
+			if err != nil {
+				u.logEvent(fmt.Sprintf("Warning: skipped malformed CSV rows: %v", err))
+			}
+			u.logEvent(fmt.Sprintf("Imported results for %d players from %s", len(result.PlayerStats), path))
+			u.QueueUpdate(func() {
+				u.statsTable.SetFailedDemos(0)
+				u.statsTable.UpdateData(result)
+			})
+		}()
+	})
+}
+
+// onExportCSVClicked writes the shown results to a timestamped CSV in the
+// config directory.
+func (u *UI) onExportCSVClicked() {
+	result := u.statsTable.data
+	if result == nil {
+		u.logEvent("Error: No results to export, run Analyze first")
+		return
+	}
+
+	path, err := exportPath(time.Now().In(u.config.Preferences.Location()), ".csv")
+	if err != nil {
+		u.logEvent(fmt.Sprintf("Error: %v", err))
+		return
+	}
+
+	go func() {
+		if err := ExportCSV(result, path); err != nil {
+			u.logEvent(fmt.Sprintf("Error exporting CSV: %v", err))
+			return
+		}
+		u.logEvent(fmt.Sprintf("Results exported to %s", path))
+	}()
+}
+
+// onExportJSONClicked writes the shown results to a timestamped JSON file in
+// the config directory, for use by other tools.
+func (u *UI) onExportJSONClicked() {
+	result := u.statsTable.data
+	if result == nil {
+		u.logEvent("Error: No results to export, run Analyze first")
+		return
+	}
+
+	path, err := exportPath(time.Now().In(u.config.Preferences.Location()), ".json")
+	if err != nil {
+		u.logEvent(fmt.Sprintf("Error: %v", err))
+		return
+	}
+
+	go func() {
+		f, err := os.Create(path)
+		if err != nil {
+			u.logEvent(fmt.Sprintf("Error exporting JSON: %v", err))
+			return
+		}
+		err = ExportJSON(result, f)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			u.logEvent(fmt.Sprintf("Error exporting JSON: %v", err))
+			return
+		}
+		u.logEvent(fmt.Sprintf("Results exported to %s", path))
+	}()
 }
 
 func (u *UI) onClearClicked(form *tview.Form) {
@@ -446,46 +1376,327 @@ func (u *UI) onClearClicked(form *tview.Form) {
 	u.logEvent("Form cleared")
 }
 
+func (u *UI) onOpenLogClicked() {
+	path := GetLogFilePath()
+	if path == "" {
+		u.logEvent("Error: Log file is not available")
+		return
+	}
+
+	// Openers may block until they exit, so keep them off the UI goroutine
+	go func() {
+		if err := openPath(path); err != nil {
+			u.logEvent(fmt.Sprintf("Could not open log file (%v). Log is at: %s", err, path))
+			return
+		}
+		u.logEvent(fmt.Sprintf("Opened log file: %s", path))
+	}()
+}
+
+// onExportChartsClicked asks for a folder and writes the shown results'
+// charts there as PNG and SVG images.
+func (u *UI) onExportChartsClicked() {
+	result := u.statsTable.data.WithAliases(u.statsTable.aliases)
+	if result == nil {
+		u.logEvent("Error: No results to chart, run Analyze first")
+		return
+	}
+
+	start, _ := configDir()
+	u.showDirectoryBrowser("Choose Chart Folder", start, func(dir string) {
+		go func() {
+			paths, err := ExportCharts(result, dir)
+			if err != nil {
+				u.logEvent(fmt.Sprintf("Error exporting charts: %v", err))
+				return
+			}
+			u.logEvent(fmt.Sprintf("Exported %d charts to %s", len(paths), dir))
+		}()
+	})
+}
+
+// onBrowseClicked lets the user pick the demo folder, starting from the
+// current base path.
+func (u *UI) onBrowseClicked(form *tview.Form) {
+	pathField, ok := form.GetFormItemByLabel(basePathLabel).(*tview.InputField)
+	if !ok {
+		return
+	}
+	u.showDirectoryBrowser("Choose Demo Folder", pathField.GetText(), func(dir string) {
+		pathField.SetText(dir)
+		u.logEvent(fmt.Sprintf("Demo folder set to %s", dir))
+	})
+}
+
+// onResetConfigClicked asks for confirmation before resetting config.json.
+func (u *UI) onResetConfigClicked() {
+	modal := tview.NewModal().
+		SetText("Reset config.json to defaults?\nThe current file is kept as config.json.bak.").
+		AddButtons([]string{"Reset", "Cancel"}).
+		SetDoneFunc(func(_ int, label string) {
+			u.Pages.RemovePage(confirmResetPage)
+			u.App.SetFocus(u.actions)
+			if label == "Reset" {
+				u.resetConfig()
+			}
+		})
+	u.Pages.AddPage(confirmResetPage, modal, true, true)
+}
+
+// resetConfig resets config.json and clears the form to match.
+func (u *UI) resetConfig() {
+	if err := ResetConfig(); err != nil {
+		u.logEvent(fmt.Sprintf("Error resetting config: %v", err))
+		return
+	}
+
+	u.config = DefaultConfig()
+	u.statsTable.SetAliases(nil)
+	for i := 0; i < u.form.GetFormItemCount(); i++ {
+		if field, ok := u.form.GetFormItem(i).(*tview.InputField); ok {
+			field.SetText("")
+		}
+	}
+	applyConfigToForm(u.form, u.config)
+	u.logEvent("Config reset to defaults (previous config saved as config.json.bak)")
+}
+
 func (u *UI) extractConfigFromForm(form *tview.Form) AnalysisConfig {
 	config := AnalysisConfig{}
 
-	// Extract player data (5 pairs of name + steamID)
-	for i := 0; i < 5; i++ {
-		nameIdx := i * 2
-		steamIdx := i*2 + 1
-
-		if nameField, ok := form.GetFormItem(nameIdx).(*tview.InputField); ok {
+	// Fields are looked up by label so their order in the form doesn't matter
+	for i := range config.Players {
+		if nameField, ok := form.GetFormItemByLabel(playerNameLabel(i)).(*tview.InputField); ok {
 			config.Players[i].Name = nameField.GetText()
 		}
-		if steamField, ok := form.GetFormItem(steamIdx).(*tview.InputField); ok {
+		if steamField, ok := form.GetFormItemByLabel(playerSteamLabel(i)).(*tview.InputField); ok {
 			config.Players[i].SteamID64 = steamField.GetText()
 		}
 	}
 
-	// Extract base path (index 10 = after 5 player pairs)
-	if pathField, ok := form.GetFormItem(10).(*tview.InputField); ok {
+	if pathField, ok := form.GetFormItemByLabel(basePathLabel).(*tview.InputField); ok {
 		config.BasePath = pathField.GetText()
 	}
 
+	if minField, ok := form.GetFormItemByLabel(minPlayersLabel).(*tview.InputField); ok {
+		config.MinPlayersPresent, _ = strconv.Atoi(minField.GetText())
+	}
+
+	if latestField, ok := form.GetFormItemByLabel(latestLabel).(*tview.InputField); ok {
+		config.LatestN, _ = strconv.Atoi(latestField.GetText())
+	}
+
+	if sourceDropDown, ok := form.GetFormItemByLabel(demoSourceLabel).(*tview.DropDown); ok {
+		_, label := sourceDropDown.GetCurrentOption()
+		config.DemoSource = label
+		for _, option := range demoSourceOptions {
+			if option.label == label {
+				config.DemoSource = option.source
+			}
+		}
+	}
+
 	return config
 }
 
 
+// applyConfigToForm fills the form fields from a loaded config.
+func applyConfigToForm(form *tview.Form, cfg *Config) {
+	if teamDropDown, ok := form.GetFormItemByLabel(teamLabel).(*tview.DropDown); ok {
+		options := []string{noTeamOption}
+		for _, team := range cfg.Teams {
+			options = append(options, team.Name)
+		}
+		teams := cfg.Teams
+		teamDropDown.SetOptions(options, func(_ string, index int) {
+			if index > 0 {
+				applyTeamToForm(form, teams[index-1])
+			}
+		})
+		teamDropDown.SetCurrentOption(0)
+	}
+
+	for i, player := range cfg.Players {
+		if i >= 5 {
+			break
+		}
+		if nameField, ok := form.GetFormItemByLabel(playerNameLabel(i)).(*tview.InputField); ok {
+			nameField.SetText(player.Name)
+		}
+		if steamField, ok := form.GetFormItemByLabel(playerSteamLabel(i)).(*tview.InputField); ok {
+			steamField.SetText(player.SteamID64)
+		}
+	}
+
+	if pathField, ok := form.GetFormItemByLabel(basePathLabel).(*tview.InputField); ok {
+		pathField.SetText(cfg.BasePath)
+	}
+
+	if sourceDropDown, ok := form.GetFormItemByLabel(demoSourceLabel).(*tview.DropDown); ok {
+		index := slices.IndexFunc(demoSourceOptions, func(option demoSourceOption) bool {
+			return option.source == cfg.Preferences.DemoSource
+		})
+		if index < 0 {
+			index = sourceDropDown.GetOptionCount()
+			sourceDropDown.AddOption(cfg.Preferences.DemoSource, nil)
+		}
+		sourceDropDown.SetCurrentOption(index)
+	}
+}
+
+// applyTeamToForm replaces the player fields with team's roster.
+func applyTeamToForm(form *tview.Form, team TeamConfig) {
+	for i := 0; i < 5; i++ {
+		var player PlayerConfig
+		if i < len(team.Players) {
+			player = team.Players[i]
+		}
+		if nameField, ok := form.GetFormItemByLabel(playerNameLabel(i)).(*tview.InputField); ok {
+			nameField.SetText(player.Name)
+		}
+		if steamField, ok := form.GetFormItemByLabel(playerSteamLabel(i)).(*tview.InputField); ok {
+			steamField.SetText(player.SteamID64)
+		}
+	}
+}
+
+// configAliases maps SteamID64 to alias for players with an alias set,
+// including team members. Aliases in players win over a team's.
+func configAliases(cfg *Config) map[string]string {
+	aliases := make(map[string]string)
+	for _, team := range cfg.Teams {
+		for _, player := range team.Players {
+			if player.Alias != "" && player.SteamID64 != "" {
+				aliases[player.SteamID64] = player.Alias
+			}
+		}
+	}
+	for _, player := range cfg.Players {
+		if player.Alias != "" && player.SteamID64 != "" {
+			aliases[player.SteamID64] = player.Alias
+		}
+	}
+	return aliases
+}
+
+// saveConfig stores the form's players and base path, keeping preferences.
+func (u *UI) saveConfig(config AnalysisConfig) {
+	// Aliases are only edited in config.json, so carry them over by SteamID64
+	aliases := configAliases(u.config)
+
+	u.config.Players = u.config.Players[:0]
+	for _, player := range config.Players {
+		if player.Name == "" && player.SteamID64 == "" {
+			continue
+		}
+		u.config.Players = append(u.config.Players, PlayerConfig{
+			Name:      player.Name,
+			SteamID64: player.SteamID64,
+			Alias:     aliases[player.SteamID64],
+		})
+	}
+	u.config.BasePath = config.BasePath
+	if config.DemoSource != "" {
+		u.config.Preferences.DemoSource = config.DemoSource
+	}
+
+	if err := SaveConfig(u.config); err != nil {
+		u.logEvent(fmt.Sprintf("Warning: could not save config: %v", err))
+	}
+}
+
+// resolvePlayers converts players entered as a SteamID2, SteamID3 or profile
+// URL to SteamID64s in config, and shows them in the form and saved config.
+// It logs the problem and returns false if a player can't be resolved.
+func (u *UI) resolvePlayers(config *AnalysisConfig, apiKey string) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), steamAPITimeout)
+	defer cancel()
+
+	resolved := false
+	for i := range config.Players {
+		input := config.Players[i].SteamID64
+		if input == "" || isSteamID64(input) {
+			continue
+		}
+		steamID, err := ResolveSteamIDWithKey(ctx, input, apiKey)
+		if err != nil {
+			u.logEvent(fmt.Sprintf("Error: Player %d: %v", i+1, err))
+			return false
+		}
+		u.logEvent(fmt.Sprintf("Resolved %s to %s", input, steamID))
+		config.Players[i].SteamID64 = steamID
+		resolved = true
+	}
+
+	if resolved {
+		players := *config
+		u.QueueUpdate(func() {
+			for i, player := range players.Players {
+				if field, ok := u.form.GetFormItemByLabel(playerSteamLabel(i)).(*tview.InputField); ok {
+					field.SetText(player.SteamID64)
+				}
+			}
+			u.saveConfig(players)
+		})
+	}
+	return true
+}
+
+// beginRun makes a cancellable context for an analysis or recompute. The
+// returned func must be called when the run ends.
+func (u *UI) beginRun() (context.Context, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	u.runMu.Lock()
+	u.runID++
+	id := u.runID
+	u.cancelRun = cancel
+	u.runMu.Unlock()
+
+	return ctx, func() {
+		cancel()
+		u.runMu.Lock()
+		if u.runID == id {
+			u.cancelRun = nil
+		}
+		u.runMu.Unlock()
+	}
+}
+
+// cancelRunning cancels the current run, reporting whether there was one.
+func (u *UI) cancelRunning() bool {
+	u.runMu.Lock()
+	defer u.runMu.Unlock()
+	if u.cancelRun == nil {
+		return false
+	}
+	u.cancelRun()
+	u.cancelRun = nil
+	return true
+}
+
+func (u *UI) onCancelClicked() {
+	if !u.cancelRunning() {
+		u.logEvent("Nothing to cancel")
+	}
+}
+
 func (u *UI) runAnalysis(config AnalysisConfig) {
 	// Add panic recovery to catch crashes and log them
 	defer func() {
 		if r := recover(); r != nil {
+			LogPanic(r)
 			u.logEvent(fmt.Sprintf("PANIC during analysis: %v", r))
 		}
 	}()
+
+	ctx, done := u.beginRun()
+	defer done()
 	
 	u.logEvent("Starting analysis...")
 
-	// Extract valid SteamIDs
-	var steamIDs []string
 	for _, player := range config.Players {
 		if player.SteamID64 != "" {
-			steamIDs = append(steamIDs, player.SteamID64)
 			u.logEvent(fmt.Sprintf("Tracking player: %s (%s)",
 				player.Name, player.SteamID64))
 		}
@@ -493,7 +1704,17 @@ func (u *UI) runAnalysis(config AnalysisConfig) {
 
 	// Gather demos
 	u.logEvent(fmt.Sprintf("Searching for demos in: %s", config.BasePath))
-	matches, err := GatherAllDemosFromPath(config.BasePath)
+	if config.LatestN > 0 {
+		u.logEvent(fmt.Sprintf("Only parsing the %d most recent demos", config.LatestN))
+	}
+	if source := config.Preferences.DemoSource; source != string(constants.DemoSourceValve) {
+		u.logEvent(fmt.Sprintf("Parsing demos with demo source %s", source))
+	}
+	matches, report, err := GatherLatestDemosWithReport(ctx, config.BasePath, config.LatestN, config.Preferences.ParserSource())
+	if errors.Is(err, context.Canceled) {
+		u.logEvent("Analysis cancelled by user")
+		return
+	}
 
 	if err != nil {
 		// Check if this is a fatal error (empty path, path doesn't exist, etc.)
@@ -512,34 +1733,129 @@ func (u *UI) runAnalysis(config AnalysisConfig) {
 
 	u.logEvent(fmt.Sprintf("Found %d demos, starting analysis...", len(matches)))
 
-	// Process matches
-	result, err := ProcessMatches(matches, steamIDs)
-	if err != nil {
-		u.logEvent(fmt.Sprintf("Error during analysis: %v", err))
+	u.processAndDisplay(ctx, matches, report.Count(GatherStatusFailed), config)
+}
+
+// runRecompute re-runs aggregation on already parsed matches.
+func (u *UI) runRecompute(matches []*api.Match, failedDemos int, config AnalysisConfig) {
+	defer func() {
+		if r := recover(); r != nil {
+			LogPanic(r)
+			u.logEvent(fmt.Sprintf("PANIC during recompute: %v", r))
+		}
+	}()
+
+	ctx, done := u.beginRun()
+	defer done()
+
+	u.logEvent(fmt.Sprintf("Recomputing stats for %d cached matches...", len(matches)))
+	u.processAndDisplay(ctx, matches, failedDemos, config)
+}
+
+// playerLabel returns the shown name for steamID in result, falling back to
+// the SteamID64 itself.
+func playerLabel(result *WrangleResult, steamID string) string {
+	for _, playerStats := range result.PlayerStats {
+		if playerStats.SteamID64 == steamID {
+			return playerStats.ShownName()
+		}
+	}
+	return steamID
+}
+
+// processAndDisplay aggregates matches for the configured players and shows
+// the result in the statistics table. failedDemos is the number of demos that
+// could not be parsed, shown as a partial-data warning. If ctx is cancelled
+// the table keeps showing the previous results.
+func (u *UI) processAndDisplay(ctx context.Context, matches []*api.Match, failedDemos int, config AnalysisConfig) {
+	var steamIDs []string
+	for _, player := range config.Players {
+		if player.SteamID64 != "" {
+			steamIDs = append(steamIDs, player.SteamID64)
+		}
+	}
+
+	// Keep all matches cached for Recompute; only aggregate the selected ones
+	dated, dropped := FilterMatchesByDate(matches, config.DateFrom, config.DateTo)
+	if dropped > 0 {
+		u.logEvent(fmt.Sprintf("Skipped %d matches outside the date range", dropped))
+	}
+	selected, dropped := FilterMatchesByRoster(dated, steamIDs, config.MinPlayersPresent)
+	if dropped > 0 {
+		u.logEvent(fmt.Sprintf("Skipped %d matches with fewer than %d tracked players", dropped, config.MinPlayersPresent))
+	}
+	if len(selected) == 0 {
+		u.logEvent("Error: No matches left to analyze")
 		return
 	}
 
+	// Process matches, unless this exact combination was computed before
+	key := resultKey(dated, steamIDs, config.Preferences, config.RoundRange, config.MinPlayersPresent)
+	result, cached := u.results.get(key)
+	if cached {
+		u.logEvent("Using cached results for these matches and settings")
+	} else {
+		var err error
+		result, err = ProcessMatches(ctx, selected, steamIDs, config.Preferences, config.RoundRange)
+		if errors.Is(err, context.Canceled) {
+			u.logEvent("Analysis cancelled by user")
+			return
+		}
+		if err != nil {
+			u.logEvent(fmt.Sprintf("Error during analysis: %v", err))
+			return
+		}
+		u.results.put(key, result)
+	}
+
 	// Display results
 	u.logEvent(fmt.Sprintf("Analysis complete! Processed %d matches", result.TotalMatches))
 	u.logEvent(fmt.Sprintf("Found stats for %d players across %d maps",
 		len(result.PlayerStats), len(result.MapList)))
+	for _, mapName := range slices.Sorted(maps.Keys(result.TopFraggerByMap)) {
+		u.logEvent(fmt.Sprintf("Top fragger on %s: %s", mapName, result.TopFraggerByMap[mapName]))
+	}
+	if pair := result.BestPair(minDuoRounds); pair != nil {
+		u.logEvent(fmt.Sprintf("Best duo: %s + %s, %.1f%% of %d rounds won together",
+			playerLabel(result, pair.SteamIDA), playerLabel(result, pair.SteamIDB), pair.WinRate, pair.RoundsTogether))
+	}
+	for _, diagnostic := range result.Diagnostics {
+		u.logEvent(diagnostic)
+	}
 
 	u.QueueUpdate(func() {
+		u.matches = matches
+		u.failedDemos = failedDemos
+		u.statsTable.SetFailedDemos(failedDemos)
 		u.statsTable.UpdateData(result)
 	})
 }
 
 
 func New() *UI {
+	colorsEnabled = detectColorSupport()
+	if !colorsEnabled {
+		applyMonochromeTheme()
+	}
+
 	app := tview.NewApplication()
 
 	// Create components
 	form := createPlayerInputForm()
+	actions := createActionsForm()
 	eventLog := newEventLog(50) // Keep last 50 events
 	statsTable := newStatisticsTable()
+	nameFilter := tview.NewInputField().
+		SetLabel("Find player: ").
+		SetPlaceholder("name or alias, Ctrl+F").
+		SetChangedFunc(statsTable.SetNameFilter)
+	viewSelect := newViewSelect()
 
 	// Create layout
-	leftPanel := form
+	leftPanel := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(form, 0, 1, true).
+		AddItem(actions, actionsHeight, 0, false)
 
 	middlePanel := eventLog.textView
 	middlePanel.SetBorder(true).
@@ -552,6 +1868,9 @@ func New() *UI {
 	rightColumn := tview.NewFlex().
 		SetDirection(tview.FlexRow).
 		AddItem(middlePanel, eventLogHeight, 0, false). // Fixed height for event log
+		AddItem(tview.NewFlex().                        // Player search and view presets
+			AddItem(nameFilter, 0, 1, false).
+			AddItem(viewSelect, viewSelectWidth, 0, false), 1, 0, false).
 		AddItem(bottomPanel, 0, 1, false)               // Rest for statistics table
 
 	mainLayout := tview.NewFlex().
@@ -561,33 +1880,121 @@ func New() *UI {
 	pages := tview.NewPages().AddPage("main", mainLayout, true, true)
 
 	app.SetRoot(pages, true).EnableMouse(true)
-	app.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-		switch event.Key() {
-		case tcell.KeyESC, tcell.KeyCtrlC:
-			app.Stop()
-			return nil
-		}
-		return event
-	})
-
 	ui := &UI{
 		App:        app,
 		Pages:      pages,
 		Root:       mainLayout,
 		form:       form,
+		actions:    actions,
 		eventLog:   eventLog,
 		statsTable: statsTable,
+		nameFilter: nameFilter,
+		viewSelect: viewSelect,
+		results:    newResultCache(),
+	}
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		LogError("Loading config: %v", err)
+		eventLog.LogError(fmt.Sprintf("Could not load config, using defaults: %v", err))
+	}
+	ui.config = cfg
+	if _, err := time.LoadLocation(cfg.Preferences.TimeZone); err != nil {
+		eventLog.LogError(fmt.Sprintf("Unknown time zone %q, using local time", cfg.Preferences.TimeZone))
+	}
+	eventLog.SetLocation(cfg.Preferences.Location())
+	applyConfigToForm(form, cfg)
+	statsTable.SetAliases(configAliases(cfg))
+	if cfg.LastView != nil {
+		statsTable.ApplyView(*cfg.LastView)
 	}
+	ui.updateViewSelect("")
+
+	ui.keys.add("Cancel a running analysis, else quit", func() {
+		if !ui.cancelRunning() {
+			app.Stop()
+		}
+	}, tcell.KeyESC)
+	ui.keys.add("Quit", app.Stop, tcell.KeyCtrlC)
+	ui.keys.add("Open the log file", ui.onOpenLogClicked, tcell.KeyCtrlO)
+	ui.keys.add("Toggle compact statistics", statsTable.ToggleCompact, tcell.KeyCtrlT)
+	ui.keys.add("List the focused or selected player's matches", statsTable.ToggleMatches, tcell.KeyCtrlP)
+	ui.keys.add("Export charts as PNG and SVG images", ui.onExportChartsClicked, tcell.KeyCtrlE)
+	ui.keys.add("Find a player in the statistics", func() { app.SetFocus(nameFilter) }, tcell.KeyCtrlF)
+	ui.keys.add("Choose a saved view", func() { app.SetFocus(viewSelect) }, tcell.KeyCtrlW)
+	ui.keys.add("Show or hide this help", ui.toggleHelp, tcell.KeyF1)
+	ui.keys.addRunes(statsTable.table, "Cycle the statistics map filter", statsTable.CycleMapFilter, 'm')
+	ui.keys.addRunes(statsTable.table, "Cycle the statistics side filter", statsTable.CycleSideFilter, 's')
+
+	// Enter or Tab moves on to the filtered table
+	nameFilter.SetDoneFunc(func(key tcell.Key) {
+		if key == tcell.KeyEnter || key == tcell.KeyTab {
+			app.SetFocus(statsTable.table)
+		}
+	})
+
+	app.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		// ESC dismisses an open dialog instead of quitting
+		if name, _ := pages.GetFrontPage(); event.Key() == tcell.KeyESC && name != "main" {
+			return event
+		}
+		// ESC in a non-empty player search clears it instead of quitting
+		if event.Key() == tcell.KeyESC && app.GetFocus() == nameFilter && nameFilter.GetText() != "" {
+			nameFilter.SetText("")
+			return nil
+		}
+		if ui.keys.handle(event, app.GetFocus()) {
+			return nil
+		}
+		return event
+	})
 
 	// Setup handlers after UI is created
 	ui.setupFormHandlers(form)
+	ui.setupActionHandlers(actions)
 
 	return ui
 }
 
 
+// centered places p in the middle of the screen at a fixed size, for dialogs
+// shown on top of the main layout.
+func centered(p tview.Primitive, width, height int) tview.Primitive {
+	return tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().
+			SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(p, height, 0, true).
+			AddItem(nil, 0, 1, false), width, 0, true).
+		AddItem(nil, 0, 1, false)
+}
+
+// Start runs the UI until it is stopped, then saves the table's view. Logs
+// set to go to stdout are written to the log file instead meanwhile.
 func (u *UI) Start() error {
-	return u.App.Run()
+	if err := LogToFileOnly(); err != nil {
+		u.eventLog.LogError(fmt.Sprintf("Logging disabled while the UI runs: %v", err))
+	}
+	err := u.App.Run()
+	u.saveLastView()
+	return err
+}
+
+// saveLastView stores the statistics table's filter and sort order in the
+// config if they changed, so the next start shows the same view.
+func (u *UI) saveLastView() {
+	var view *ViewPreset
+	if current := u.statsTable.currentView(""); current != (ViewPreset{}) {
+		view = &current
+	}
+	if view == u.config.LastView || view != nil && u.config.LastView != nil && *view == *u.config.LastView {
+		return
+	}
+	u.config.LastView = view
+	if err := SaveConfig(u.config); err != nil {
+		LogError("Cannot save the table view: %v", err)
+	}
 }
 
 func (u *UI) Stop() {
@@ -599,6 +2006,7 @@ func (u *UI) QueueUpdate(fn func()) {
 }
 
 func (u *UI) logEvent(message string) {
+	LogInfo("%s", message)
 	u.QueueUpdate(func() {
 		u.eventLog.Log(message)
 	})
@@ -0,0 +1,246 @@
+package manalyzer
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akiver/cs-demo-analyzer/pkg/api"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// startTestUI runs the application on a simulation screen, with a temporary
+// config directory, until the test ends.
+func startTestUI(t *testing.T) *UI {
+	t.Helper()
+	useTestHome(t)
+	u := New()
+	u.App.SetScreen(tcell.NewSimulationScreen(""))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := u.App.Run(); err != nil {
+			t.Error(err)
+		}
+	}()
+	t.Cleanup(func() {
+		u.App.Stop()
+		<-done
+	})
+	return u
+}
+
+// onUI runs fn on the application's goroutine and waits for it.
+func onUI(u *UI, fn func()) {
+	done := make(chan struct{})
+	u.QueueUpdate(func() {
+		fn()
+		close(done)
+	})
+	<-done
+}
+
+func TestMapSummaryRowSkipsUnplayedSide(t *testing.T) {
+	// Twelve rounds: alice only plays CT.
+	match := newTestMatch("de_mirage", mr12HalfLength)
+	for n := 1; n <= mr12HalfLength; n += 2 {
+		addKill(match, n, 300, alice, carol)
+		addDamage(match, n, 200, alice, carol, 90)
+	}
+	addKill(match, 2, 300, dave, alice)
+	result := processTestMatches(t, []*api.Match{match}, alice)
+	mapStats := testPlayerStats(t, result, alice).MapStats["de_mirage"]
+	if side := mapStats.SideStats["T"]; side == nil || side.RoundsPlayed != 0 {
+		t.Fatalf("T side is %+v, want one with no rounds", side)
+	}
+
+	st := newStatisticsTable()
+	st.addDataRow(1, "alice", "de_mirage", "CT", mapStats.SideStats["CT"])
+	st.addMapSummaryRow(2, "alice", "de_mirage", mapStats)
+	for col := 4; col < st.table.GetColumnCount(); col++ { // After the side and matches columns
+		ct, both := st.table.GetCell(1, col).Text, st.table.GetCell(2, col).Text
+		if ct != both {
+			t.Errorf("column %s: Both row has %q, CT row %q", st.table.GetCell(0, col).Text, both, ct)
+		}
+	}
+}
+
+func TestCompactRowCounts(t *testing.T) {
+	matches := []*api.Match{newTestMatch("de_mirage", 24), newTestMatch("de_nuke", 24)}
+	st := newStatisticsTable()
+	st.UpdateData(processTestMatches(t, matches, alice, bob))
+
+	// Header, the players' rows and the team row. In detail each player has
+	// T, CT and Both for each map and an overall row.
+	detailed, compact := 1+2*(2*3+1)+1, 1+2+1
+	for _, tt := range []struct {
+		compact bool
+		want    int
+	}{{false, detailed}, {true, compact}, {false, detailed}} {
+		st.SetCompact(tt.compact)
+		if got := st.table.GetRowCount(); got != tt.want {
+			t.Errorf("compact %v: %d rows, want %d", tt.compact, got, tt.want)
+		}
+	}
+}
+
+func TestNoColor(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+	if detectColorSupport() {
+		t.Error("colors enabled with NO_COLOR set")
+	}
+
+	tests := []struct{ text, want string }{
+		{"[yellow]12:00:00[-] [red]ERROR:[-] failed", "12:00:00 ERROR: failed"},
+		{"[red::b](PARTIAL)[-::-]", "(PARTIAL)"},
+		{"[#ff0000]red[-]", "red"},
+		{tview.Escape("[T]"), tview.Escape("[T]")}, // Escaped brackets are text
+	}
+	for _, tt := range tests {
+		if got := stripColorTags(tt.text); got != tt.want {
+			t.Errorf("stripColorTags(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+
+	saved := colorsEnabled
+	colorsEnabled = false
+	t.Cleanup(func() { colorsEnabled = saved })
+	el := newEventLog(10)
+	el.LogError("demo failed")
+	if text := el.textView.GetText(false); strings.ContainsAny(text, "[]") || !strings.Contains(text, "ERROR: demo failed") {
+		t.Errorf("event log shows %q, want it without color tags", text)
+	}
+}
+
+func TestRecomputeUsesNewPreferences(t *testing.T) {
+	u := startTestUI(t)
+
+	// Alice's ADR is 10, 10 and 70: a mean of 30 and a median of 10
+	var matches []*api.Match
+	for _, health := range []int{10, 10, 70} {
+		match := newTestMatch("de_mirage", 24)
+		for n := 1; n <= 24; n++ {
+			addDamage(match, n, 300, alice, testOpponent(match, alice, n), health)
+		}
+		matches = append(matches, match)
+	}
+
+	recompute := func(mode string, want float64) {
+		t.Helper()
+		cfg := DefaultConfig()
+		cfg.Preferences.AverageMode = mode
+		if err := SaveConfig(cfg); err != nil {
+			t.Fatal(err)
+		}
+		onUI(u, func() {
+			u.matches = matches
+			u.statsTable.data = nil
+			u.form.GetFormItemByLabel(playerSteamLabel(0)).(*tview.InputField).SetText(strconv.FormatUint(alice, 10))
+			u.onRecomputeClicked(u.form)
+		})
+
+		var result *WrangleResult
+		for deadline := time.Now().Add(5 * time.Second); result == nil && time.Now().Before(deadline); {
+			time.Sleep(10 * time.Millisecond)
+			onUI(u, func() { result = u.statsTable.data })
+		}
+		if result == nil {
+			t.Fatalf("%s: recompute showed no results", mode)
+		}
+		if got := testPlayerStats(t, result, alice).OverallStats.ADR; !closeTo(got, want) {
+			t.Errorf("%s: ADR = %v after recompute, want %v", mode, got, want)
+		}
+	}
+	recompute(AverageModeMean, 30)
+	recompute(AverageModeMedian, 10)
+}
+
+func TestPartialDataBanner(t *testing.T) {
+	st := newStatisticsTable()
+	tests := []struct {
+		failed  int
+		partial bool
+	}{{0, false}, {2, true}, {0, false}}
+	for _, tt := range tests {
+		st.SetFailedDemos(tt.failed)
+		title := st.table.GetTitle()
+		if partial := strings.Contains(title, "PARTIAL: 2 demos failed"); partial != tt.partial {
+			t.Errorf("%d failed demos: title %q, want the banner %v", tt.failed, title, tt.partial)
+		}
+	}
+}
+
+func TestFormFieldsReadByLabel(t *testing.T) {
+	// A new field ahead of all the others shifts every index
+	form := tview.NewForm().AddInputField("Demo Pattern", "*.dem", 10, nil, nil)
+	original := createPlayerInputForm()
+	for i := range original.GetFormItemCount() {
+		form.AddFormItem(original.GetFormItem(i))
+	}
+
+	cfg := DefaultConfig()
+	cfg.BasePath = "/demos"
+	cfg.Players = []PlayerConfig{{Name: "alice", SteamID64: strconv.FormatUint(alice, 10)}}
+	applyConfigToForm(form, cfg)
+
+	config := (&UI{}).extractConfigFromForm(form)
+	if config.BasePath != "/demos" {
+		t.Errorf("BasePath = %q, want /demos", config.BasePath)
+	}
+	if player := config.Players[0]; player.Name != "alice" || player.SteamID64 != cfg.Players[0].SteamID64 {
+		t.Errorf("player 1 = %+v, want alice", player)
+	}
+}
+
+func TestFocusDimsOtherPlayers(t *testing.T) {
+	saved := colorsEnabled
+	colorsEnabled = true
+	t.Cleanup(func() { colorsEnabled = saved })
+
+	st := newStatisticsTable()
+	st.UpdateData(processTestMatches(t, []*api.Match{newTestMatch("de_mirage", 24)}, alice, bob))
+	aliceID := strconv.FormatUint(alice, 10)
+
+	// dimmed reports for each player whether all their cells are dimmed,
+	// failing if only some are.
+	dimmed := func() map[string]bool {
+		t.Helper()
+		got := make(map[string]bool)
+		for row, steamID := range st.rowPlayers {
+			for col := range st.table.GetColumnCount() {
+				cell := st.table.GetCell(row, col)
+				// Cells without a style keep colors in the legacy fields
+				color, attrs := cell.Color, cell.Attributes
+				if cell.Style != tcell.StyleDefault {
+					color, _, attrs = cell.Style.Decompose()
+				}
+				dim := attrs&tcell.AttrDim != 0 && color == tcell.ColorGray
+				if prev, seen := got[steamID]; seen && prev != dim {
+					t.Fatalf("row %d column %d: dimmed %v, other cells of %s %v", row, col, dim, steamID, prev)
+				}
+				got[steamID] = dim
+			}
+		}
+		return got
+	}
+
+	if got := dimmed(); len(got) != 2 || got[aliceID] || got[strconv.FormatUint(bob, 10)] {
+		t.Errorf("without focus dimmed = %v, want both players shown normally", got)
+	}
+	st.ToggleFocus(aliceID)
+	if got := dimmed(); got[aliceID] || !got[strconv.FormatUint(bob, 10)] {
+		t.Errorf("alice focused: dimmed = %v, want only bob dimmed", got)
+	}
+	st.ToggleFocus(aliceID)
+	if got := dimmed(); got[aliceID] || got[strconv.FormatUint(bob, 10)] {
+		t.Errorf("focus cleared: dimmed = %v, want nobody dimmed", got)
+	}
+
+	st.SetFocus(aliceID)
+	st.UpdateData(processTestMatches(t, []*api.Match{newTestMatch("de_mirage", 24)}, bob))
+	if st.focus != "" || dimmed()[strconv.FormatUint(bob, 10)] {
+		t.Error("focus kept after the focused player left the data")
+	}
+}
@@ -0,0 +1,107 @@
+package manalyzer
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+const helpPage = "help"
+
+// keyBinding is a shortcut, either special keys or plain runes. A binding
+// with a scope only applies while that primitive has focus, so runes can be
+// bound without stealing them from text fields.
+type keyBinding struct {
+	keys        []tcell.Key
+	runes       []rune
+	scope       tview.Primitive
+	description string
+	action      func()
+}
+
+// keyBindings is the registry consulted by the application input capture.
+// The help overlay is generated from it, so every shortcut registered here
+// is listed there.
+type keyBindings struct {
+	bindings []keyBinding
+}
+
+// add registers action for keys.
+func (kb *keyBindings) add(description string, action func(), keys ...tcell.Key) {
+	kb.bindings = append(kb.bindings, keyBinding{keys: keys, description: description, action: action})
+}
+
+// addRunes registers action for runes typed while scope has focus.
+func (kb *keyBindings) addRunes(scope tview.Primitive, description string, action func(), runes ...rune) {
+	kb.bindings = append(kb.bindings, keyBinding{runes: runes, scope: scope, description: description, action: action})
+}
+
+// handle runs the binding for event's key, reporting whether there was one.
+// focused is the primitive with focus, checked against scoped bindings.
+func (kb *keyBindings) handle(event *tcell.EventKey, focused tview.Primitive) bool {
+	for _, binding := range kb.bindings {
+		if binding.matches(event, focused) {
+			binding.action()
+			return true
+		}
+	}
+	return false
+}
+
+// matches reports whether event triggers the binding.
+func (binding keyBinding) matches(event *tcell.EventKey, focused tview.Primitive) bool {
+	if binding.scope != nil && binding.scope != focused {
+		return false
+	}
+	if event.Key() == tcell.KeyRune {
+		// Alt+rune is left to the terminal and widgets
+		return event.Modifiers()&tcell.ModAlt == 0 && slices.Contains(binding.runes, event.Rune())
+	}
+	return slices.Contains(binding.keys, event.Key())
+}
+
+// cheatSheet lists every binding, one per line, in registration order.
+func (kb *keyBindings) cheatSheet() string {
+	var b strings.Builder
+	for _, binding := range kb.bindings {
+		names := make([]string, 0, len(binding.keys)+len(binding.runes))
+		for _, key := range binding.keys {
+			names = append(names, keyName(key))
+		}
+		for _, r := range binding.runes {
+			names = append(names, string(r))
+		}
+		fmt.Fprintf(&b, "%-14s %s\n", strings.Join(names, " / "), binding.description)
+	}
+	return b.String()
+}
+
+// keyName returns the display name of key, e.g. "Ctrl+O" or "F1".
+func keyName(key tcell.Key) string {
+	if name, ok := tcell.KeyNames[key]; ok {
+		return strings.ReplaceAll(name, "Ctrl-", "Ctrl+")
+	}
+	return fmt.Sprintf("Key %d", key)
+}
+
+// toggleHelp shows or hides the keybinding overlay.
+func (u *UI) toggleHelp() {
+	if u.Pages.HasPage(helpPage) {
+		u.Pages.RemovePage(helpPage)
+		return
+	}
+
+	sheet := u.keys.cheatSheet()
+	text := tview.NewTextView().SetText(sheet + "\nESC or Enter to close")
+	text.SetBorder(true).SetTitle("Keyboard Shortcuts").SetTitleAlign(tview.AlignLeft)
+	text.SetDoneFunc(func(tcell.Key) {
+		u.Pages.RemovePage(helpPage)
+	})
+
+	// Borders, padding and the closing hint
+	height := strings.Count(sheet, "\n") + 4
+	u.Pages.AddPage(helpPage, centered(text, 60, height), true, true)
+}
@@ -0,0 +1,47 @@
+package manalyzer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+func TestKeyBindings(t *testing.T) {
+	table := tview.NewTable()
+	input := tview.NewInputField()
+	var helps, cycles int
+	var kb keyBindings
+	kb.add("Help", func() { helps++ }, tcell.KeyF1)
+	kb.addRunes(table, "Cycle", func() { cycles++ }, 'm')
+
+	m := tcell.NewEventKey(tcell.KeyRune, 'm', tcell.ModNone)
+	tests := []struct {
+		name    string
+		event   *tcell.EventKey
+		focused tview.Primitive
+		handled bool
+	}{
+		{"key anywhere", tcell.NewEventKey(tcell.KeyF1, 0, tcell.ModNone), input, true},
+		{"rune in scope", m, table, true},
+		{"rune out of scope", m, input, false},
+		{"unbound rune", tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModNone), table, false},
+		{"Alt+rune", tcell.NewEventKey(tcell.KeyRune, 'm', tcell.ModAlt), table, false},
+	}
+	for _, tt := range tests {
+		if got := kb.handle(tt.event, tt.focused); got != tt.handled {
+			t.Errorf("%s: handled = %v, want %v", tt.name, got, tt.handled)
+		}
+	}
+	if helps != 1 || cycles != 1 {
+		t.Errorf("ran help %d and cycle %d times, want once each", helps, cycles)
+	}
+
+	sheet := kb.cheatSheet()
+	for _, line := range []string{"F1", "m              Cycle"} {
+		if !strings.Contains(sheet, line) {
+			t.Errorf("cheat sheet lacks %q:\n%s", line, sheet)
+		}
+	}
+}
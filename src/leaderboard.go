@@ -0,0 +1,118 @@
+package manalyzer
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// leaderboardMetrics maps metric names, matching the OverallStatistics JSON
+// keys, to their values.
+var leaderboardMetrics = map[string]func(*OverallStatistics) float64{
+	"kast":                  func(s *OverallStatistics) float64 { return s.KAST },
+	"adr":                   func(s *OverallStatistics) float64 { return s.ADR },
+	"kd":                    func(s *OverallStatistics) float64 { return s.KD },
+	"killDeathDiffPerRound": func(s *OverallStatistics) float64 { return s.KillDeathDiffPerRound },
+	"rws":                   func(s *OverallStatistics) float64 { return s.RWS },
+	"kills":                 func(s *OverallStatistics) float64 { return float64(s.Kills) },
+	"deaths":                func(s *OverallStatistics) float64 { return float64(s.Deaths) },
+	"assists":               func(s *OverallStatistics) float64 { return float64(s.Assists) },
+	"headshots":             func(s *OverallStatistics) float64 { return float64(s.Headshots) },
+	"firstKills":            func(s *OverallStatistics) float64 { return float64(s.FirstKills) },
+	"firstDeaths":           func(s *OverallStatistics) float64 { return float64(s.FirstDeaths) },
+	"tradeKills":            func(s *OverallStatistics) float64 { return float64(s.TradeKills) },
+	"tradeDeaths":           func(s *OverallStatistics) float64 { return float64(s.TradeDeaths) },
+	"flashAssists":          func(s *OverallStatistics) float64 { return float64(s.FlashAssists) },
+	"flashEfficiency":       func(s *OverallStatistics) float64 { return s.FlashEfficiency },
+	"utilityDamage":         func(s *OverallStatistics) float64 { return float64(s.UtilityDamage) },
+	"clutchWins":            func(s *OverallStatistics) float64 { return float64(s.ClutchWins) },
+	"clutchWinRate":         func(s *OverallStatistics) float64 { return s.ClutchWinRate },
+	"openingDuelWinRate":    func(s *OverallStatistics) float64 { return s.OpeningDuelWinRate },
+	"roundWinRate":          func(s *OverallStatistics) float64 { return s.RoundWinRate },
+	"pistolRoundWinRate":    func(s *OverallStatistics) float64 { return s.PistolRoundWinRate },
+	"roundsPlayed":          func(s *OverallStatistics) float64 { return float64(s.RoundsPlayed) },
+	"matchesPlayed":         func(s *OverallStatistics) float64 { return float64(s.MatchesPlayed) },
+}
+
+// LeaderboardMetrics returns the metric names accepted by ExportLeaderboard.
+func LeaderboardMetrics() []string {
+	names := make([]string, 0, len(leaderboardMetrics))
+	for name := range leaderboardMetrics {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// ExportLeaderboard writes the players ranked by an overall metric, highest
+// first, as CSV with rank, name and value columns. Players without overall
+// stats are left out; equal values share a rank.
+func ExportLeaderboard(result *WrangleResult, metric string, w io.Writer) error {
+	header, rows, err := leaderboardRows(result, metric)
+	if err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w)
+	cw.Write(header)
+	cw.WriteAll(rows)
+	return cw.Error()
+}
+
+// ExportLeaderboardMarkdown is ExportLeaderboard as a Markdown table, for
+// chat and forum posts.
+func ExportLeaderboardMarkdown(result *WrangleResult, metric string, w io.Writer) error {
+	header, rows, err := leaderboardRows(result, metric)
+	if err != nil {
+		return err
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "| %s |\n|---|---|---:|\n", strings.Join(header, " | "))
+	for _, row := range rows {
+		// Pipes would split the cell
+		row[1] = strings.ReplaceAll(row[1], "|", `\|`)
+		fmt.Fprintf(&b, "| %s |\n", strings.Join(row, " | "))
+	}
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// leaderboardRows ranks result's players by metric for ExportLeaderboard.
+func leaderboardRows(result *WrangleResult, metric string) (header []string, rows [][]string, err error) {
+	if result == nil {
+		return nil, nil, fmt.Errorf("no results to export")
+	}
+	value, ok := leaderboardMetrics[metric]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown metric %q (want one of %s)", metric, strings.Join(LeaderboardMetrics(), ", "))
+	}
+
+	players := make([]*PlayerStats, 0, len(result.PlayerStats))
+	for _, playerStats := range result.PlayerStats {
+		if playerStats.OverallStats != nil {
+			players = append(players, playerStats)
+		}
+	}
+	sort.SliceStable(players, func(i, j int) bool {
+		vi, vj := value(players[i].OverallStats), value(players[j].OverallStats)
+		if vi != vj {
+			return vi > vj
+		}
+		return players[i].PlayerName < players[j].PlayerName
+	})
+
+	rows = make([][]string, 0, len(players))
+	rank := 0
+	for i, playerStats := range players {
+		v := value(playerStats.OverallStats)
+		if i == 0 || v != value(players[i-1].OverallStats) {
+			rank = i + 1
+		}
+		rows = append(rows, []string{strconv.Itoa(rank), playerStats.ShownName(), strconv.FormatFloat(v, 'f', 2, 64)})
+	}
+
+	return []string{"Rank", "Player", metric}, rows, nil
+}
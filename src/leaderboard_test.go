@@ -0,0 +1,51 @@
+package manalyzer
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func leaderboardTestResult() *WrangleResult {
+	player := func(name string, adr float64) *PlayerStats {
+		return &PlayerStats{PlayerName: name, OverallStats: &OverallStatistics{ADR: adr}}
+	}
+	return &WrangleResult{PlayerStats: []*PlayerStats{
+		player("carol", 71.5),
+		player("alice", 88.25),
+		{PlayerName: "dave"}, // No overall stats
+		player("bob", 71.5),
+	}}
+}
+
+func TestExportLeaderboardSortsByADR(t *testing.T) {
+	var buf bytes.Buffer
+	if err := ExportLeaderboard(leaderboardTestResult(), "adr", &buf); err != nil {
+		t.Fatal(err)
+	}
+	// Ties share a rank and are ordered by name
+	want := "Rank,Player,adr\n1,alice,88.25\n2,bob,71.50\n2,carol,71.50\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestExportLeaderboardMarkdown(t *testing.T) {
+	result := leaderboardTestResult()
+	result.PlayerStats[1].PlayerName = "a|ice"
+
+	var buf bytes.Buffer
+	if err := ExportLeaderboardMarkdown(result, "adr", &buf); err != nil {
+		t.Fatal(err)
+	}
+	want := "| Rank | Player | adr |\n|---|---|---:|\n| 1 | a\\|ice | 88.25 |\n| 2 | bob | 71.50 |\n| 2 | carol | 71.50 |\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestExportLeaderboardUnknownMetric(t *testing.T) {
+	if err := ExportLeaderboard(leaderboardTestResult(), "elo", io.Discard); err == nil {
+		t.Error("unknown metric exported without error")
+	}
+}
@@ -0,0 +1,354 @@
+package manalyzer
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const logFileName = "manalyzer.log"
+
+// defaultLogMaxSizeMB is the default Preferences.LogMaxSizeMB.
+const defaultLogMaxSizeMB = 5
+
+// logBackups is how many rotated logs (manalyzer.log.1 and up) are kept.
+const logBackups = 3
+
+// homeEnvVar overrides the config/log directory when set.
+const homeEnvVar = "MANALYZER_HOME"
+
+var (
+	// logMu guards the logger state below, which InitLogger, LogToFileOnly
+	// and CloseLogger replace while other goroutines may be logging.
+	// Logging itself only takes the read lock.
+	logMu sync.RWMutex
+
+	appLogger   *log.Logger
+	logFile     *rotatingFile
+	logFilePath string
+	logTarget   string
+
+	// syslogWriter is the syslog connection for LogTargetSyslog.
+	syslogWriter io.WriteCloser
+
+	// minLogLevel is the least severe level written, see SetLogLevel.
+	minLogLevel = logLevels[LogLevelInfo]
+
+	// logMaxSize is the log file size in bytes that triggers rotation.
+	logMaxSize int64 = defaultLogMaxSizeMB << 20
+
+	homeDirOverride string
+
+	// stdoutWriter receives logs for LogTargetStdout.
+	stdoutWriter io.Writer = os.Stdout
+)
+
+// logLevels orders the Preferences.LogLevel values by severity.
+var logLevels = map[string]int{
+	LogLevelDebug: 0,
+	LogLevelInfo:  1,
+	LogLevelWarn:  2,
+	LogLevelError: 3,
+}
+
+// SetLogLevel drops messages less severe than level ("debug", "info",
+// "warn" or "error"). An unknown level logs from info up.
+func SetLogLevel(level string) {
+	severity, ok := logLevels[level]
+	if !ok {
+		severity = logLevels[LogLevelInfo]
+	}
+	logMu.Lock()
+	defer logMu.Unlock()
+	minLogLevel = severity
+}
+
+// SetLogMaxSize sets the size in MB at which the log file is rotated. Call
+// it before InitLogger; values below 1 keep the default.
+func SetLogMaxSize(megabytes int) {
+	if megabytes < 1 {
+		megabytes = defaultLogMaxSizeMB
+	}
+	logMu.Lock()
+	defer logMu.Unlock()
+	logMaxSize = int64(megabytes) << 20
+}
+
+// SetHomeDir overrides the directory used for config and logs, taking
+// precedence over MANALYZER_HOME. Call it before InitLogger.
+func SetHomeDir(dir string) {
+	homeDirOverride = dir
+}
+
+// configDir returns the directory where manalyzer keeps its config and logs:
+// the SetHomeDir override, then $MANALYZER_HOME, then the OS config directory.
+func configDir() (string, error) {
+	if homeDirOverride != "" {
+		return homeDirOverride, nil
+	}
+	if dir := os.Getenv(homeEnvVar); dir != "" {
+		return dir, nil
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot determine config directory: %w", err)
+	}
+	return filepath.Join(dir, "manalyzer"), nil
+}
+
+// InitLogger routes logs to target (LogTargetFile, LogTargetSyslog or
+// LogTargetStdout). Unknown targets, and syslog where it is unavailable, fall
+// back to the log file in the config directory. Until it succeeds, log calls
+// are silently dropped. Calling it again replaces the previous target.
+func InitLogger(target string) error {
+	syslogErr, err := initLogger(target)
+	if syslogErr != nil && err == nil {
+		LogWarn("syslog unavailable, logging to %s: %v", GetLogFilePath(), syslogErr)
+	}
+	return err
+}
+
+// initLogger is InitLogger under logMu. It also returns why syslog was
+// unavailable, to be logged once the lock is released.
+func initLogger(target string) (syslogErr, err error) {
+	logMu.Lock()
+	defer logMu.Unlock()
+
+	closeLogFile()
+	logTarget = target
+	switch target {
+	case LogTargetStdout:
+		appLogger = log.New(stdoutWriter, "", log.LstdFlags)
+		return nil, nil
+	case LogTargetSyslog:
+		w, err := newSyslogWriter()
+		if err == nil {
+			syslogWriter = w
+			// syslog adds its own timestamps
+			appLogger = log.New(w, "", 0)
+			return nil, nil
+		}
+		return err, openLogFile()
+	default:
+		return nil, openLogFile()
+	}
+}
+
+// openLogFile opens (or creates) the log file in the config directory.
+// logMu must be held.
+func openLogFile() error {
+	dir, err := configDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("cannot create log directory: %w", err)
+	}
+
+	path := filepath.Join(dir, logFileName)
+	rf := &rotatingFile{path: path, maxSize: logMaxSize}
+	if err := rf.open(); err != nil {
+		return fmt.Errorf("cannot open log file: %w", err)
+	}
+	// A log left over from a previous run may already be over the limit.
+	// If it can't be rotated, keep appending to it.
+	if rf.size >= rf.maxSize {
+		if err := rf.rotate(); err != nil && rf.file == nil {
+			return fmt.Errorf("cannot open log file: %w", err)
+		}
+	}
+
+	logFile = rf
+	logFilePath = path
+	appLogger = log.New(rf, "", log.LstdFlags)
+	return nil
+}
+
+// rotatingFile is the log file. A write that would take it past maxSize
+// first moves it to path.1, shifting older logs up to path.<logBackups>.
+// Writes come from many goroutines, so they are serialized by mu.
+type rotatingFile struct {
+	mu      sync.Mutex
+	path    string
+	file    *os.File
+	size    int64
+	maxSize int64
+
+	// retrySize, when set, is the size at which to rotate again after a
+	// failed rotation, so that a file that can't be renamed (e.g. held
+	// open on Windows) isn't retried on every write.
+	retrySize int64
+}
+
+// open opens path for appending and records its size.
+func (rf *rotatingFile) open() error {
+	f, err := os.OpenFile(rf.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	rf.file = f
+	rf.size = info.Size()
+	return nil
+}
+
+func (rf *rotatingFile) Write(p []byte) (int, error) {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	if rf.file == nil {
+		return 0, os.ErrClosed
+	}
+	if rf.size > 0 && rf.size+int64(len(p)) > max(rf.maxSize, rf.retrySize) {
+		// Keep logging to the old file rather than losing the message
+		if err := rf.rotate(); err != nil && rf.file == nil {
+			return 0, err
+		}
+	}
+	n, err := rf.file.Write(p)
+	rf.size += int64(n)
+	return n, err
+}
+
+// rotate shifts the old logs, moves the current one to path.1 and opens a
+// fresh file. The file is closed before renaming because Windows can't
+// rename open files. If renaming fails, the current file is reopened and
+// the next attempt waits until it has grown by another maxSize.
+func (rf *rotatingFile) rotate() error {
+	rf.file.Close()
+	rf.file = nil
+
+	var err error
+	for i := logBackups - 1; i >= 1; i-- {
+		older := fmt.Sprintf("%s.%d", rf.path, i)
+		if _, statErr := os.Stat(older); statErr == nil {
+			if renameErr := os.Rename(older, fmt.Sprintf("%s.%d", rf.path, i+1)); renameErr != nil {
+				err = renameErr
+			}
+		}
+	}
+	if renameErr := os.Rename(rf.path, rf.path+".1"); renameErr != nil {
+		err = renameErr
+	}
+
+	if openErr := rf.open(); openErr != nil {
+		return openErr
+	}
+	rf.retrySize = 0
+	if err != nil {
+		rf.retrySize = rf.size + rf.maxSize
+	}
+	return err
+}
+
+// Close closes the file; later writes fail.
+func (rf *rotatingFile) Close() error {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	if rf.file == nil {
+		return nil
+	}
+	err := rf.file.Close()
+	rf.file = nil
+	return err
+}
+
+// LogToFileOnly moves logging from stdout to the log file, for while the
+// terminal UI owns the screen and log lines would draw over it. Other
+// targets are left alone. If the file can't be opened, logs are dropped.
+func LogToFileOnly() error {
+	logMu.Lock()
+	defer logMu.Unlock()
+
+	if logTarget != LogTargetStdout || appLogger == nil {
+		return nil
+	}
+	logTarget = LogTargetFile
+	if err := openLogFile(); err != nil {
+		appLogger = nil
+		return err
+	}
+	return nil
+}
+
+// CloseLogger flushes and closes the log file or syslog connection.
+func CloseLogger() {
+	logMu.Lock()
+	defer logMu.Unlock()
+
+	closeLogFile()
+	appLogger = nil
+}
+
+// closeLogFile closes the log file or syslog connection, if one is open.
+// logMu must be held.
+func closeLogFile() {
+	if logFile != nil {
+		logFile.Close()
+	}
+	if syslogWriter != nil {
+		syslogWriter.Close()
+	}
+	logFile = nil
+	logFilePath = ""
+	syslogWriter = nil
+}
+
+// GetLogFilePath returns the path of the active log file, or "" if logs are
+// not going to a file.
+func GetLogFilePath() string {
+	logMu.RLock()
+	defer logMu.RUnlock()
+	return logFilePath
+}
+
+// logAt writes a message at level, unless SetLogLevel filters it out.
+func logAt(level, prefix, format string, args ...any) {
+	logMu.RLock()
+	defer logMu.RUnlock()
+
+	if appLogger == nil || logLevels[level] < minLogLevel {
+		return
+	}
+	appLogger.Printf(prefix+format, args...)
+}
+
+// LogDebug writes a diagnostic message, only logged at the debug level.
+func LogDebug(format string, args ...any) {
+	logAt(LogLevelDebug, "DEBUG: ", format, args...)
+}
+
+// LogInfo writes an informational message to the log file.
+func LogInfo(format string, args ...any) {
+	logAt(LogLevelInfo, "INFO: ", format, args...)
+}
+
+// LogWarn writes a warning: something went wrong but was worked around.
+func LogWarn(format string, args ...any) {
+	logAt(LogLevelWarn, "WARN: ", format, args...)
+}
+
+// LogError writes an error message to the log file.
+func LogError(format string, args ...any) {
+	logAt(LogLevelError, "ERROR: ", format, args...)
+}
+
+// LogPanic records a recovered panic value.
+func LogPanic(r any) {
+	logMu.RLock()
+	defer logMu.RUnlock()
+
+	if appLogger == nil {
+		return
+	}
+	appLogger.Printf("PANIC: %v", r)
+}
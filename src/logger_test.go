@@ -0,0 +1,181 @@
+package manalyzer
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// useTestHome points the config and log directory at a temporary one and
+// closes the logger when the test ends.
+func useTestHome(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	SetHomeDir(dir)
+	t.Cleanup(func() {
+		CloseLogger()
+		SetHomeDir("")
+	})
+	return dir
+}
+
+func TestStdoutLogTarget(t *testing.T) {
+	useTestHome(t)
+	var out bytes.Buffer
+	saved := stdoutWriter
+	stdoutWriter = &out
+	t.Cleanup(func() { stdoutWriter = saved })
+
+	if err := InitLogger(LogTargetStdout); err != nil {
+		t.Fatal(err)
+	}
+	LogInfo("hello %s", "stdout")
+	if !strings.Contains(out.String(), "INFO: hello stdout") {
+		t.Errorf("stdout got %q", out.String())
+	}
+	if path := GetLogFilePath(); path != "" {
+		t.Errorf("log file %s opened for the stdout target", path)
+	}
+}
+
+// writeLogLines writes each line to rf.
+func writeLogLines(t *testing.T, rf *rotatingFile, lines ...string) {
+	t.Helper()
+	for _, line := range lines {
+		if _, err := rf.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// readLog returns the contents of path, or "" if it doesn't exist.
+func readLog(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestRotatingFileKeepsBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), logFileName)
+	rf := &rotatingFile{path: path, maxSize: 10}
+	if err := rf.open(); err != nil {
+		t.Fatal(err)
+	}
+	defer rf.Close()
+
+	// Each line fills the file, so every later write rotates it.
+	writeLogLines(t, rf, "line 1\n", "line 2\n", "line 3\n", "line 4\n", "line 5\n")
+
+	want := map[string]string{
+		path:        "line 5\n",
+		path + ".1": "line 4\n",
+		path + ".2": "line 3\n",
+		path + ".3": "line 2\n",
+		path + ".4": "",
+	}
+	for file, content := range want {
+		if got := readLog(t, file); got != content {
+			t.Errorf("%s = %q, want %q", filepath.Base(file), got, content)
+		}
+	}
+}
+
+func TestRotatingFileBacksOffAfterFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), logFileName)
+	// Non-empty directories in the way of every rename make rotation fail.
+	for i := 1; i <= logBackups; i++ {
+		blocker := fmt.Sprintf("%s.%d", path, i)
+		if err := os.MkdirAll(filepath.Join(blocker, "x"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rf := &rotatingFile{path: path, maxSize: 10}
+	if err := rf.open(); err != nil {
+		t.Fatal(err)
+	}
+	defer rf.Close()
+
+	writeLogLines(t, rf, "line 1\n", "line 2\n")
+	if got := readLog(t, path); got != "line 1\nline 2\n" {
+		t.Fatalf("after a failed rotation the log is %q", got)
+	}
+
+	// Once renaming works again, the next attempt waits for another
+	// maxSize of logs rather than coming on the next write.
+	for i := 1; i <= logBackups; i++ {
+		if err := os.RemoveAll(fmt.Sprintf("%s.%d", path, i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeLogLines(t, rf, "3\n")
+	if got := readLog(t, path); got != "line 1\nline 2\n3\n" {
+		t.Fatalf("rotated again right after failing: log is %q", got)
+	}
+	writeLogLines(t, rf, "line 4\n")
+	if got, old := readLog(t, path), readLog(t, path+".1"); got != "line 4\n" || old != "line 1\nline 2\n3\n" {
+		t.Errorf("log is %q and .1 is %q after backing off", got, old)
+	}
+}
+
+// TestConcurrentLogging logs from several goroutines while the logger is
+// replaced and closed. Run with -race.
+func TestConcurrentLogging(t *testing.T) {
+	useTestHome(t)
+	if err := InitLogger(LogTargetFile); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				LogInfo("goroutine %d message %d", g, i)
+				LogError("goroutine %d error %d", g, i)
+				_ = GetLogFilePath()
+			}
+		}()
+	}
+	for i := 0; i < 20; i++ {
+		if err := InitLogger(LogTargetFile); err != nil {
+			t.Error(err)
+		}
+		if i%5 == 0 {
+			CloseLogger()
+		}
+	}
+	wg.Wait()
+}
+
+func TestHomeEnvVar(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(homeEnvVar, dir)
+	t.Cleanup(CloseLogger)
+
+	if err := InitLogger(LogTargetFile); err != nil {
+		t.Fatal(err)
+	}
+	LogInfo("hello")
+	if err := SaveConfig(DefaultConfig()); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{logFileName, configFileName} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("%s not in %s: %v", name, homeEnvVar, err)
+		}
+	}
+
+	// SetHomeDir takes precedence
+	override := useTestHome(t)
+	if got, _ := configPath(); filepath.Dir(got) != override {
+		t.Errorf("config path %s is not in the SetHomeDir directory %s", got, override)
+	}
+}
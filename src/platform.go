@@ -0,0 +1,68 @@
+package manalyzer
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// commandRunner runs an external command and returns its combined output.
+// It is a variable so it can be substituted, e.g. to stub out process launches.
+var commandRunner = func(name string, args ...string) ([]byte, error) {
+	return exec.Command(name, args...).CombinedOutput()
+}
+
+// lookPath resolves a command name; replaceable like commandRunner.
+var lookPath = exec.LookPath
+
+// openerCommands lists the commands tried, in order, to open path on goos.
+func openerCommands(goos, path string) [][]string {
+	switch goos {
+	case "windows":
+		return [][]string{
+			{"rundll32", "url.dll,FileProtocolHandler", path},
+			{"cmd", "/c", "start", "", path},
+		}
+	case "darwin":
+		return [][]string{
+			{"open", path},
+		}
+	default:
+		return [][]string{
+			{"xdg-open", path},
+			{"gio", "open", path},
+		}
+	}
+}
+
+// openPath opens a file or URL with the platform's default handler, falling
+// back to the next opener when one is missing or fails. The returned error
+// includes each failed command's output.
+func openPath(path string) error {
+	var errs []error
+
+	for _, command := range openerCommands(runtime.GOOS, path) {
+		name, args := command[0], command[1:]
+		if _, err := lookPath(name); err != nil {
+			errs = append(errs, fmt.Errorf("%s: not found", name))
+			continue
+		}
+
+		output, err := commandRunner(name, args...)
+		if err == nil {
+			return nil
+		}
+
+		if detail := strings.TrimSpace(string(output)); detail != "" {
+			err = fmt.Errorf("%w: %s", err, detail)
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", name, err))
+	}
+
+	if len(errs) == 0 {
+		return fmt.Errorf("no opener available for %s", runtime.GOOS)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,76 @@
+package manalyzer
+
+import (
+	"errors"
+	"os/exec"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// stubOpeners replaces lookPath and commandRunner until the test ends.
+// Commands in missing are not found; the others run through run.
+func stubOpeners(t *testing.T, missing []string, run func(name string, args ...string) ([]byte, error)) {
+	t.Helper()
+	savedLookPath, savedRunner := lookPath, commandRunner
+	t.Cleanup(func() { lookPath, commandRunner = savedLookPath, savedRunner })
+	lookPath = func(name string) (string, error) {
+		if slices.Contains(missing, name) {
+			return "", exec.ErrNotFound
+		}
+		return "/usr/bin/" + name, nil
+	}
+	commandRunner = run
+}
+
+func TestOpenerCommands(t *testing.T) {
+	for _, goos := range []string{"windows", "darwin", "linux", "freebsd"} {
+		commands := openerCommands(goos, "/tmp/chart.png")
+		if len(commands) == 0 {
+			t.Errorf("%s: no openers", goos)
+		}
+		for _, command := range commands {
+			if command[len(command)-1] != "/tmp/chart.png" {
+				t.Errorf("%s: %v does not end with the path", goos, command)
+			}
+		}
+	}
+}
+
+func TestOpenPathFallsBack(t *testing.T) {
+	commands := openerCommands(runtime.GOOS, "/tmp/chart.png")
+	if len(commands) < 2 {
+		t.Skipf("%s has a single opener", runtime.GOOS)
+	}
+	first, second := commands[0][0], commands[1][0]
+
+	var ran []string
+	stubOpeners(t, nil, func(name string, args ...string) ([]byte, error) {
+		ran = append(ran, name)
+		if name == first {
+			return []byte("no handler\n"), errors.New("exit status 1")
+		}
+		return nil, nil
+	})
+	if err := openPath("/tmp/chart.png"); err != nil {
+		t.Errorf("openPath: %v", err)
+	}
+	if want := []string{first, second}; !slices.Equal(ran, want) {
+		t.Errorf("ran %v, want %v", ran, want)
+	}
+
+	// The first opener is missing and the second fails: both are reported
+	stubOpeners(t, []string{first}, func(name string, args ...string) ([]byte, error) {
+		return []byte("cannot open display\n"), errors.New("exit status 2")
+	})
+	err := openPath("/tmp/chart.png")
+	if err == nil {
+		t.Fatal("openPath succeeded with every opener failing")
+	}
+	for _, want := range []string{first + ": not found", second + ": exit status 2: cannot open display"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q lacks %q", err, want)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package manalyzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// resultFileVersion is bumped whenever the saved WrangleResult layout changes
+// incompatibly. LoadResult migrates files from every earlier version.
+const resultFileVersion = 3
+
+const resultFileName = "results.json"
+
+// savedResult is the on-disk envelope written by SaveResult.
+type savedResult struct {
+	Version int            `json:"version"`
+	SavedAt time.Time      `json:"savedAt"`
+	Result  *WrangleResult `json:"result"`
+}
+
+// defaultResultPath returns the results file location in the config directory.
+func defaultResultPath() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, resultFileName), nil
+}
+
+// exportPath returns a timestamped path with extension ext in the config
+// directory, so exports don't overwrite each other or the files read by
+// Load Results and Import CSV.
+func exportPath(now time.Time, ext string) (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "results-"+now.Format("20060102-150405")+ext), nil
+}
+
+// ExportJSON writes result as indented JSON: the full tree of players, maps,
+// sides and overall stats, without SaveResult's envelope. Players without
+// overall stats have none in the output.
+func ExportJSON(result *WrangleResult, w io.Writer) error {
+	if result == nil {
+		return fmt.Errorf("no results to export")
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(result); err != nil {
+		return fmt.Errorf("cannot encode results: %w", err)
+	}
+	return nil
+}
+
+// SaveResult writes result to path as versioned JSON, stamping the save time
+// in loc (local time when nil).
+func SaveResult(result *WrangleResult, path string, loc *time.Location) error {
+	if result == nil {
+		return fmt.Errorf("no results to save")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("cannot create results directory: %w", err)
+	}
+
+	if loc == nil {
+		loc = time.Local
+	}
+
+	data, err := json.MarshalIndent(savedResult{
+		Version: resultFileVersion,
+		SavedAt: time.Now().In(loc),
+		Result:  result,
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("cannot encode results: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("cannot write results: %w", err)
+	}
+
+	return nil
+}
+
+// LoadResult reads a WrangleResult previously written by SaveResult.
+func LoadResult(path string) (*WrangleResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read results: %w", err)
+	}
+
+	var saved savedResult
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("invalid results file %s: %w", path, err)
+	}
+	if saved.Version < 1 || saved.Version > resultFileVersion {
+		return nil, fmt.Errorf("unsupported results version %d (expected %d)", saved.Version, resultFileVersion)
+	}
+	if saved.Result == nil {
+		return nil, fmt.Errorf("results file %s has no data", path)
+	}
+
+	restoreMaps(saved.Result)
+	disambiguateNames(saved.Result.PlayerStats)
+	if saved.Version < 2 {
+		migrateDamageData(saved.Result)
+	}
+	if saved.Version < 3 {
+		migrateDamageRounds(saved.Result)
+	}
+	return saved.Result, nil
+}
+
+// migrateDamageData upgrades a version 1 result, saved before sides recorded
+// HasDamageData. Version 1 counted every side's ADR, so every side is marked
+// as having damage data to keep the ADR it was saved with.
+func migrateDamageData(result *WrangleResult) {
+	for _, playerStats := range result.PlayerStats {
+		if playerStats == nil {
+			continue
+		}
+		for _, mapStats := range playerStats.MapStats {
+			for _, stats := range mapStats.SideStats {
+				stats.HasDamageData = true
+			}
+			for _, matchStats := range mapStats.Matches {
+				for _, stats := range matchStats.SideStats {
+					stats.HasDamageData = true
+				}
+			}
+		}
+	}
+}
+
+// migrateDamageRounds upgrades a result saved before sides recorded
+// DamageRounds. Their ADR was per round played, so a side with damage data
+// counts all its rounds.
+func migrateDamageRounds(result *WrangleResult) {
+	migrate := func(stats *SideStatistics) {
+		if stats.HasDamageData && stats.DamageRounds == 0 {
+			stats.DamageRounds = stats.RoundsPlayed
+		}
+	}
+	for _, playerStats := range result.PlayerStats {
+		if playerStats == nil {
+			continue
+		}
+		for _, mapStats := range playerStats.MapStats {
+			for _, stats := range mapStats.SideStats {
+				migrate(stats)
+			}
+			for _, matchStats := range mapStats.Matches {
+				for _, stats := range matchStats.SideStats {
+					migrate(stats)
+				}
+			}
+		}
+	}
+}
+
+// restoreMaps recreates maps dropped by omitempty, or missing from an
+// import, so callers can index them directly.
+func restoreMaps(result *WrangleResult) {
+	for _, playerStats := range result.PlayerStats {
+		if playerStats == nil {
+			continue
+		}
+		if playerStats.MapStats == nil {
+			playerStats.MapStats = make(map[string]*MapStatistics)
+		}
+		if playerStats.WeaponCategoryKills == nil {
+			playerStats.WeaponCategoryKills = make(map[string]int)
+		}
+		if playerStats.WeaponKills == nil {
+			playerStats.WeaponKills = make(map[string]int)
+		}
+		for _, mapStats := range playerStats.MapStats {
+			if mapStats.SideStats == nil {
+				mapStats.SideStats = make(map[string]*SideStatistics)
+			}
+			restoreSideMaps(mapStats.SideStats)
+			for _, matchStats := range mapStats.Matches {
+				if matchStats.SideStats == nil {
+					matchStats.SideStats = make(map[string]*SideStatistics)
+				}
+				restoreSideMaps(matchStats.SideStats)
+			}
+		}
+	}
+}
+
+func restoreSideMaps(sideStats map[string]*SideStatistics) {
+	for _, stats := range sideStats {
+		if stats.DamageByPhase == nil {
+			stats.DamageByPhase = make(map[string]int)
+		}
+		if stats.OpeningDuelsByPhase == nil {
+			stats.OpeningDuelsByPhase = make(map[string]int)
+		}
+	}
+}
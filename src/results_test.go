@@ -0,0 +1,62 @@
+package manalyzer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/akiver/cs-demo-analyzer/pkg/api"
+)
+
+func TestSaveAndLoadResult(t *testing.T) {
+	path := filepath.Join(t.TempDir(), resultFileName)
+	match := newTestMatch("de_mirage", 24)
+	addDamage(match, 1, 300, alice, carol, 90)
+	result := processTestMatches(t, []*api.Match{match}, alice)
+
+	if err := SaveResult(result, path, nil); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadResult(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := testPlayerStats(t, loaded, alice).MapStats["de_mirage"].SideStats["CT"]
+	if got.ADR != 90.0/12 || !got.HasDamageData {
+		t.Errorf("CT ADR = %v with damage data %v, want %v with damage data", got.ADR, got.HasDamageData, 90.0/12)
+	}
+}
+
+func TestLoadResultVersion1(t *testing.T) {
+	// Version 1 had no hasDamageData; its ADR counted for every side
+	v1 := `{"version": 1, "savedAt": "2024-01-01T00:00:00Z", "result": {"playerStats": [{
+		"steamId64": "76561198000000001", "playerName": "alice",
+		"mapStats": {"de_mirage": {"mapName": "de_mirage", "matchesPlayed": 1, "sideStats": {
+			"CT": {"side": "CT", "roundsPlayed": 12, "adr": 80},
+			"T": {"side": "T", "roundsPlayed": 12, "adr": 60}}}}}]}}`
+	path := filepath.Join(t.TempDir(), resultFileName)
+	if err := os.WriteFile(path, []byte(v1), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := LoadResult(path)
+	if err != nil {
+		t.Fatalf("version 1 results rejected: %v", err)
+	}
+	for side, stats := range testPlayerStats(t, result, alice).MapStats["de_mirage"].SideStats {
+		if !stats.HasDamageData || stats.DamageRounds != stats.RoundsPlayed {
+			t.Errorf("%s side lost its ADR: damage data %v over %d rounds after migration",
+				side, stats.HasDamageData, stats.DamageRounds)
+		}
+	}
+}
+
+func TestLoadResultRejectsNewerVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), resultFileName)
+	if err := os.WriteFile(path, []byte(`{"version": 99, "result": {}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadResult(path); err == nil {
+		t.Error("results from a newer version loaded without error")
+	}
+}
@@ -0,0 +1,152 @@
+package manalyzer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// maxAnalyzeRequestBytes bounds the body of POST /analyze.
+const maxAnalyzeRequestBytes = 1 << 20
+
+// serverShutdownTimeout bounds how long Serve waits for requests in flight
+// when stopped.
+const serverShutdownTimeout = 10 * time.Second
+
+// AnalyzeRequest is the JSON body of POST /analyze.
+type AnalyzeRequest struct {
+	Path     string   `json:"path"`     // Demo directory, searched recursively
+	SteamIDs []string `json:"steamIds"` // SteamID64s to track
+
+	// Optional, as in AnalysisConfig
+	RoundRange        [2]int `json:"roundRange,omitempty"`
+	MinPlayersPresent int    `json:"minPlayersPresent,omitempty"`
+	LatestN           int    `json:"latestN,omitempty"`
+}
+
+// apiError is the JSON body of a failed API request.
+type apiError struct {
+	Error string `json:"error"`
+}
+
+// NewAPIHandler returns the HTTP API: POST /analyze gathers the demos in the
+// request's path, aggregates them for its SteamIDs with prefs and responds
+// with the WrangleResult as JSON.
+func NewAPIHandler(prefs Preferences) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /analyze", func(w http.ResponseWriter, r *http.Request) {
+		var req AnalyzeRequest
+		decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAnalyzeRequestBytes))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, apiError{fmt.Sprintf("invalid request body: %v", err)})
+			return
+		}
+		if req.Path == "" || len(req.SteamIDs) == 0 {
+			writeJSON(w, http.StatusBadRequest, apiError{"path and steamIds are required"})
+			return
+		}
+		for _, steamID := range req.SteamIDs {
+			if _, err := strconv.ParseUint(steamID, 10, 64); err != nil {
+				writeJSON(w, http.StatusBadRequest, apiError{fmt.Sprintf("invalid SteamID64 %q", steamID)})
+				return
+			}
+		}
+
+		result, status, err := analyze(r.Context(), req, prefs)
+		if err != nil {
+			LogError("API analyze of %s failed: %v", req.Path, err)
+			writeJSON(w, status, apiError{err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, result)
+	})
+	return mux
+}
+
+// analyze runs the gather and aggregation pipeline for req, returning the
+// HTTP status to report on error. req's SteamIDs must already be valid.
+func analyze(ctx context.Context, req AnalyzeRequest, prefs Preferences) (*WrangleResult, int, error) {
+	matches, _, err := GatherLatestDemosWithReport(ctx, req.Path, req.LatestN, prefs.ParserSource())
+	if isCancelled(err) {
+		return nil, http.StatusServiceUnavailable, err
+	}
+	if len(matches) == 0 {
+		if err == nil {
+			err = ErrNoDemos
+		}
+		return nil, http.StatusUnprocessableEntity, err
+	}
+	if err != nil {
+		// Partial failures still leave matches to analyze, as in the UI
+		LogWarn("API analyze of %s: %v", req.Path, err)
+	}
+
+	matches, _ = FilterMatchesByRoster(matches, req.SteamIDs, req.MinPlayersPresent)
+	result, err := ProcessMatches(ctx, matches, req.SteamIDs, prefs, req.RoundRange)
+	if isCancelled(err) {
+		// The client went away or the server is shutting down
+		return nil, http.StatusServiceUnavailable, err
+	}
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	return result, http.StatusOK, nil
+}
+
+// isCancelled reports whether err comes from a cancelled or expired context.
+func isCancelled(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		LogError("Cannot write API response: %v", err)
+	}
+}
+
+// Serve runs the HTTP API on addr until ctx is done or the server fails. An
+// address without a host, such as ":8080", is bound to localhost so the API
+// isn't exposed to the network unless asked for. When ctx is done, running
+// analyses are cancelled and Serve shuts the server down before returning.
+func Serve(ctx context.Context, addr string, prefs Preferences) error {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid listen address %q: %w", addr, err)
+	}
+	if host == "" {
+		addr = net.JoinHostPort("localhost", port)
+	}
+
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           NewAPIHandler(prefs),
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+
+	LogInfo("Serving API on http://%s", addr)
+	failed := make(chan error, 1)
+	go func() { failed <- server.ListenAndServe() }()
+
+	select {
+	case err := <-failed:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), serverShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("cannot shut down API server: %w", err)
+	}
+	LogInfo("API server stopped")
+	return nil
+}
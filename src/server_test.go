@@ -0,0 +1,88 @@
+package manalyzer
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestAnalyzeHandler(t *testing.T) {
+	demos := t.TempDir()
+	match := newTestMatch("de_mirage", 24)
+	addKill(match, 1, 200, alice, carol)
+	writeTestDemo(t, demos, match)
+
+	body := func(path string, steamIDs ...string) string {
+		data, err := json.Marshal(AnalyzeRequest{Path: path, SteamIDs: steamIDs})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+	aliceID := strconv.FormatUint(alice, 10)
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"ok", body(demos, aliceID), http.StatusOK},
+		{"malformed body", "{", http.StatusBadRequest},
+		{"unknown field", `{"path": "/demos", "steamIds": ["1"], "players": []}`, http.StatusBadRequest},
+		{"too large", `{"path": "` + strings.Repeat("a", maxAnalyzeRequestBytes) + `"}`, http.StatusBadRequest},
+		{"missing SteamIDs", body(demos), http.StatusBadRequest},
+		{"invalid SteamID", body(demos, "STEAM_1:0:1"), http.StatusBadRequest},
+		{"no demos", body(t.TempDir(), aliceID), http.StatusUnprocessableEntity},
+	}
+
+	handler := NewAPIHandler(testPreferences())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/analyze", strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d; body %s", rec.Code, tt.status, rec.Body)
+			}
+			if tt.status != http.StatusOK {
+				var apiErr apiError
+				if err := json.Unmarshal(rec.Body.Bytes(), &apiErr); err != nil || apiErr.Error == "" {
+					t.Errorf("error body %s, want an error message", rec.Body)
+				}
+				return
+			}
+
+			var result WrangleResult
+			if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+				t.Fatalf("invalid result: %v", err)
+			}
+			if result.TotalMatches != 1 || len(result.PlayerStats) != 1 {
+				t.Fatalf("got %d matches and %d players, want 1 and 1", result.TotalMatches, len(result.PlayerStats))
+			}
+			if kills := result.PlayerStats[0].OverallStats.Kills; kills != 1 {
+				t.Errorf("kills = %d, want 1", kills)
+			}
+		})
+	}
+}
+
+func TestAnalyzeHandlerCancelled(t *testing.T) {
+	demos := t.TempDir()
+	writeTestDemo(t, demos, newTestMatch("de_mirage", 24))
+
+	req := httptest.NewRequest(http.MethodPost, "/analyze",
+		strings.NewReader(`{"path": "`+demos+`", "steamIds": ["`+strconv.FormatUint(alice, 10)+`"]}`))
+	ctx, cancel := context.WithCancel(req.Context())
+	cancel()
+	rec := httptest.NewRecorder()
+	NewAPIHandler(testPreferences()).ServeHTTP(rec, req.WithContext(ctx))
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+}
@@ -0,0 +1,112 @@
+package manalyzer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// steamID64Base is the SteamID64 of account number 0 in the public universe;
+// SteamID2 and SteamID3 only store the account number.
+const steamID64Base = 76561197960265728
+
+// steamAPITimeout bounds resolving all players' vanity URLs.
+const steamAPITimeout = 15 * time.Second
+
+// resolveVanityURL is the Steam Web API endpoint for vanity URLs.
+const resolveVanityURL = "https://api.steampowered.com/ISteamUser/ResolveVanityURL/v1/"
+
+var (
+	steamID64Pattern  = regexp.MustCompile(`^\d{17}$`)
+	steamID2Pattern   = regexp.MustCompile(`^STEAM_[0-5]:([01]):(\d+)$`)
+	steamID3Pattern   = regexp.MustCompile(`^\[U:1:(\d+)\]$`)
+	profileURLPattern = regexp.MustCompile(`^(?:https?://)?(?:www\.)?steamcommunity\.com/(profiles|id)/([^/?#]+)/?(?:[?#].*)?$`)
+)
+
+// isSteamID64 reports whether input is already a 17-digit SteamID64.
+func isSteamID64(input string) bool {
+	return steamID64Pattern.MatchString(input)
+}
+
+// ResolveSteamID converts a SteamID2 (STEAM_1:0:1234), SteamID3
+// ([U:1:2468]) or steamcommunity.com/profiles/ URL to a SteamID64. A
+// SteamID64 is returned unchanged. Vanity URLs need ResolveSteamIDWithKey.
+func ResolveSteamID(input string) (string, error) {
+	return ResolveSteamIDWithKey(context.Background(), input, "")
+}
+
+// ResolveSteamIDWithKey is ResolveSteamID that also resolves
+// steamcommunity.com/id/ vanity URLs through the Steam Web API with apiKey.
+func ResolveSteamIDWithKey(ctx context.Context, input, apiKey string) (string, error) {
+	input = strings.TrimSpace(input)
+
+	if isSteamID64(input) {
+		return input, nil
+	}
+	if m := steamID2Pattern.FindStringSubmatch(input); m != nil {
+		y, _ := strconv.ParseUint(m[1], 10, 64)
+		z, err := strconv.ParseUint(m[2], 10, 32)
+		if err != nil {
+			return "", fmt.Errorf("invalid SteamID2 %q", input)
+		}
+		return strconv.FormatUint(steamID64Base+z*2+y, 10), nil
+	}
+	if m := steamID3Pattern.FindStringSubmatch(input); m != nil {
+		account, err := strconv.ParseUint(m[1], 10, 32)
+		if err != nil {
+			return "", fmt.Errorf("invalid SteamID3 %q", input)
+		}
+		return strconv.FormatUint(steamID64Base+account, 10), nil
+	}
+	if m := profileURLPattern.FindStringSubmatch(input); m != nil {
+		if m[1] == "profiles" {
+			if !isSteamID64(m[2]) {
+				return "", fmt.Errorf("invalid profile URL %q", input)
+			}
+			return m[2], nil
+		}
+		if apiKey == "" {
+			return "", fmt.Errorf("resolving vanity URL %q needs steamApiKey in config.json", input)
+		}
+		return resolveVanity(ctx, m[2], apiKey)
+	}
+	return "", fmt.Errorf("unrecognized SteamID %q, want a SteamID64, SteamID2, SteamID3 or profile URL", input)
+}
+
+// resolveVanity looks up the SteamID64 of a vanity URL name.
+func resolveVanity(ctx context.Context, name, apiKey string) (string, error) {
+	query := url.Values{"key": {apiKey}, "vanityurl": {name}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, resolveVanityURL+"?"+query.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		// The request URL holds the API key, so don't include it
+		return "", fmt.Errorf("cannot reach the Steam Web API to resolve %q", name)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Steam Web API returned %s resolving %q", resp.Status, name)
+	}
+
+	var body struct {
+		Response struct {
+			SteamID string `json:"steamid"`
+			Success int    `json:"success"`
+		} `json:"response"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("invalid Steam Web API response: %w", err)
+	}
+	if body.Response.Success != 1 || !isSteamID64(body.Response.SteamID) {
+		return "", fmt.Errorf("no Steam profile with vanity URL %q", name)
+	}
+	return body.Response.SteamID, nil
+}
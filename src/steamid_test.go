@@ -0,0 +1,39 @@
+package manalyzer
+
+import "testing"
+
+func TestResolveSteamID(t *testing.T) {
+	// Valve's example account in every format
+	const want = "76561197960287930"
+	for _, input := range []string{
+		want,
+		" " + want + "\n",
+		"STEAM_0:0:11101",
+		"STEAM_1:0:11101",
+		"[U:1:22202]",
+		"https://steamcommunity.com/profiles/" + want + "/",
+		"steamcommunity.com/profiles/" + want + "?l=english",
+	} {
+		got, err := ResolveSteamID(input)
+		if err != nil || got != want {
+			t.Errorf("ResolveSteamID(%q) = %q, %v, want %s", input, got, err, want)
+		}
+	}
+	if got, _ := ResolveSteamID("STEAM_1:1:11101"); got != "76561197960287931" {
+		t.Errorf("SteamID2 with Y 1 = %s, want 76561197960287931", got)
+	}
+
+	for _, input := range []string{
+		"",
+		"7656119796028793",  // 16 digits
+		"STEAM_1:2:11101",   // Y is 0 or 1
+		"[U:1:99999999999]", // Account number overflows 32 bits
+		"https://steamcommunity.com/profiles/gaben",
+		"https://steamcommunity.com/id/gaben", // Vanity URL without an API key
+		"https://example.com/profiles/" + want,
+	} {
+		if got, err := ResolveSteamID(input); err == nil {
+			t.Errorf("ResolveSteamID(%q) = %q, want an error", input, got)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package manalyzer
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/akiver/cs-demo-analyzer/pkg/api"
+)
+
+// PairSynergy is how two tracked players did in rounds they played on the
+// same side.
+type PairSynergy struct {
+	SteamIDA       string  `json:"steamIdA"` // The lower SteamID64 of the pair
+	SteamIDB       string  `json:"steamIdB"`
+	RoundsTogether int     `json:"roundsTogether"`
+	RoundsWon      int     `json:"roundsWon"`
+	WinRate        float64 `json:"winRate"` // Percentage of RoundsTogether won
+	Kills          int     `json:"kills"`   // Combined kills in those rounds
+	KillsPerRound  float64 `json:"killsPerRound"`
+}
+
+// pairKey returns the PairSynergy key for two SteamID64s, lower one first.
+func pairKey(a, b uint64) string {
+	if a > b {
+		a, b = b, a
+	}
+	return fmt.Sprintf("%d+%d", a, b)
+}
+
+// accumulatePairSynergy adds match to pairs for every pair of steamIDs that
+// shared a side in at least one round.
+func accumulatePairSynergy(match *api.Match, steamIDs []uint64, pairs map[string]*PairSynergy) {
+	present := make([]*api.Player, 0, len(steamIDs))
+	for _, steamID64 := range steamIDs {
+		if player, ok := match.PlayersBySteamID[steamID64]; ok {
+			present = append(present, player)
+		}
+	}
+	if len(present) < 2 {
+		return
+	}
+
+	// Non-team kills per round and killer, counted once per match. Kills made
+	// while controlling a bot belong to the bot, as in the player stats.
+	kills := make(map[int]map[uint64]int)
+	for _, kill := range match.Kills {
+		if !isValidFrag(kill) || kill.IsKillerControllingBot {
+			continue
+		}
+		if kills[kill.RoundNumber] == nil {
+			kills[kill.RoundNumber] = make(map[uint64]int)
+		}
+		kills[kill.RoundNumber][kill.KillerSteamID64]++
+	}
+
+	for i, a := range present {
+		for _, b := range present[i+1:] {
+			if a.SteamID64 == b.SteamID64 {
+				continue // Same player entered twice
+			}
+			for _, round := range match.Rounds {
+				side := determinePlayerSideInRound(match, a, round)
+				if sideToString(side) == "" || determinePlayerSideInRound(match, b, round) != side {
+					continue
+				}
+
+				key := pairKey(a.SteamID64, b.SteamID64)
+				pair := pairs[key]
+				if pair == nil {
+					low, high := min(a.SteamID64, b.SteamID64), max(a.SteamID64, b.SteamID64)
+					pair = &PairSynergy{
+						SteamIDA: fmt.Sprint(low),
+						SteamIDB: fmt.Sprint(high),
+					}
+					pairs[key] = pair
+				}
+
+				pair.RoundsTogether++
+				if round.WinnerSide == side {
+					pair.RoundsWon++
+				}
+				pair.Kills += kills[round.Number][a.SteamID64] + kills[round.Number][b.SteamID64]
+			}
+		}
+	}
+}
+
+// finishPairSynergy fills in the derived rates once all matches are added.
+func finishPairSynergy(pairs map[string]*PairSynergy) {
+	for _, pair := range pairs {
+		pair.WinRate = percentage(pair.RoundsWon, pair.RoundsTogether)
+		if pair.RoundsTogether > 0 {
+			pair.KillsPerRound = float64(pair.Kills) / float64(pair.RoundsTogether)
+		}
+	}
+}
+
+// BestPair returns the pair with the highest win rate among those with at
+// least minRounds rounds together, or nil if there is none.
+func (r *WrangleResult) BestPair(minRounds int) *PairSynergy {
+	keys := make([]string, 0, len(r.PairSynergy))
+	for key := range r.PairSynergy {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys) // Stable choice between equal win rates
+
+	var best *PairSynergy
+	for _, key := range keys {
+		pair := r.PairSynergy[key]
+		if pair.RoundsTogether < minRounds {
+			continue
+		}
+		if best == nil || pair.WinRate > best.WinRate {
+			best = pair
+		}
+	}
+	return best
+}
@@ -0,0 +1,44 @@
+package manalyzer
+
+import (
+	"testing"
+
+	"github.com/akiver/cs-demo-analyzer/pkg/api"
+	"github.com/markus-wa/demoinfocs-golang/v4/pkg/demoinfocs/common"
+)
+
+func TestPairSynergy(t *testing.T) {
+	match := newTestMatch("de_mirage", 24)
+	for n := 1; n <= 24; n++ {
+		if n%2 == 0 {
+			match.Rounds[n-1].WinnerSide = common.TeamTerrorists
+		}
+	}
+	addKill(match, 1, 300, alice, carol)
+	addKill(match, 2, 300, bob, dave)
+	addKill(match, 3, 300, alice, bob) // Team kill
+	addKill(match, 4, 300, bob, carol).IsKillerControllingBot = true
+
+	result := processTestMatches(t, []*api.Match{match}, alice, bob, carol)
+	pair := result.PairSynergy[pairKey(alice, bob)]
+	if pair == nil {
+		t.Fatalf("no synergy for alice and bob; have %v", result.PairSynergy)
+	}
+	if pair.RoundsTogether != 24 || pair.RoundsWon != 12 || pair.WinRate != 50 {
+		t.Errorf("alice and bob won %d of %d rounds (%.1f%%), want 12 of 24 (50%%)", pair.RoundsWon, pair.RoundsTogether, pair.WinRate)
+	}
+	if pair.Kills != 2 {
+		t.Errorf("alice and bob have %d kills, want 2 without the team kill and the bot's kill", pair.Kills)
+	}
+	if pair.KillsPerRound != 2.0/24 {
+		t.Errorf("KillsPerRound = %v, want %v", pair.KillsPerRound, 2.0/24)
+	}
+
+	// Opponents never share a side
+	if pair := result.PairSynergy[pairKey(alice, carol)]; pair != nil {
+		t.Errorf("alice and carol have synergy %+v", pair)
+	}
+	if best := result.BestPair(1); best != result.PairSynergy[pairKey(alice, bob)] {
+		t.Errorf("BestPair = %+v, want alice and bob", best)
+	}
+}
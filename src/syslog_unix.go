@@ -0,0 +1,13 @@
+//go:build !windows && !plan9
+
+package manalyzer
+
+import (
+	"io"
+	"log/syslog"
+)
+
+// newSyslogWriter connects to the local syslog daemon (journald forwards it).
+func newSyslogWriter() (io.WriteCloser, error) {
+	return syslog.New(syslog.LOG_INFO|syslog.LOG_USER, "manalyzer")
+}
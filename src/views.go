@@ -0,0 +1,212 @@
+package manalyzer
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/rivo/tview"
+)
+
+const (
+	saveViewPage = "save-view"
+
+	viewNameLabel = "Name"
+	viewMapLabel  = "Map"
+	viewSideLabel = "Side"
+	viewSortLabel = "Sort By"
+	viewDescLabel = "Descending"
+
+	allOption  = "All"
+	nameOption = "Name"
+
+	// defaultViewOption is the view dropdown's first option, which clears
+	// the filter and sort order, and saveViewOption its last, which opens
+	// the Save View form.
+	defaultViewOption = "Default"
+	saveViewOption    = "Save current view..."
+
+	// viewSelectWidth is the width of the view dropdown, with its label,
+	// next to the player search.
+	viewSelectWidth = 36
+)
+
+// SetSort orders players by the overall value of a leaderboard metric, or by
+// name when column is empty or unknown.
+func (st *StatisticsTable) SetSort(column string, desc bool) {
+	st.sortColumn = column
+	st.sortDesc = desc
+	st.renderTable()
+}
+
+// less reports whether player a is listed before b. Players without overall
+// stats come last when sorting by a metric; ties are broken by name.
+func (st *StatisticsTable) less(a, b *PlayerStats) bool {
+	if value, ok := leaderboardMetrics[st.sortColumn]; ok {
+		switch {
+		case a.OverallStats == nil && b.OverallStats != nil:
+			return false
+		case a.OverallStats != nil && b.OverallStats == nil:
+			return true
+		case a.OverallStats != nil && b.OverallStats != nil:
+			va, vb := value(a.OverallStats), value(b.OverallStats)
+			if va != vb {
+				if st.sortDesc {
+					return va > vb
+				}
+				return va < vb
+			}
+		}
+	}
+	return a.PlayerName < b.PlayerName
+}
+
+// ApplyView sets the table filter and sort order from preset.
+func (st *StatisticsTable) ApplyView(preset ViewPreset) {
+	st.sortColumn = preset.SortColumn
+	st.sortDesc = preset.SortDesc
+	st.SetFilter(preset.MapFilter, preset.SideFilter)
+}
+
+// currentView returns the table's filter and sort order as a preset.
+func (st *StatisticsTable) currentView(name string) ViewPreset {
+	return ViewPreset{
+		Name:       name,
+		MapFilter:  st.filterMap,
+		SideFilter: st.filterSide,
+		SortColumn: st.sortColumn,
+		SortDesc:   st.sortDesc,
+	}
+}
+
+// newViewSelect returns the dropdown that applies view presets, filled in
+// by updateViewSelect.
+func newViewSelect() *tview.DropDown {
+	return tview.NewDropDown().
+		SetLabel(" View: ").
+		SetFieldWidth(viewSelectWidth-8).
+		SetTextOptions("", "", "", "", "-")
+}
+
+// updateViewSelect lists "Default", the saved view presets and the save
+// action in the view dropdown, showing current as chosen ("-" if it isn't a
+// preset). Choosing a view applies it to the statistics table.
+func (u *UI) updateViewSelect(current string) {
+	names := []string{defaultViewOption}
+	for _, preset := range u.config.ViewPresets {
+		names = append(names, preset.Name)
+	}
+	names = append(names, saveViewOption)
+
+	// Set the option before the handler, which SetCurrentOption would call
+	u.viewSelect.SetOptions(names, nil)
+	u.viewSelect.SetCurrentOption(slices.Index(names, current))
+	u.viewSelect.SetSelectedFunc(func(name string, index int) {
+		switch {
+		case index == 0:
+			u.statsTable.ApplyView(ViewPreset{})
+		case index > 0 && index <= len(u.config.ViewPresets):
+			preset := u.config.ViewPresets[index-1]
+			u.statsTable.ApplyView(preset)
+			u.logEvent(fmt.Sprintf("Applied view %q", preset.Name))
+		case name == saveViewOption:
+			// Keep showing the applied view until one is saved
+			u.updateViewSelect(current)
+			u.showSaveView()
+			return
+		}
+		current = name
+		u.App.SetFocus(u.statsTable.table)
+	})
+}
+
+// showSaveView opens a form to edit the current view and save it as a
+// preset. A preset with the same name is replaced.
+func (u *UI) showSaveView() {
+	current := u.statsTable.currentView("")
+
+	maps := []string{allOption}
+	if u.statsTable.data != nil {
+		maps = append(maps, slices.Sorted(slices.Values(u.statsTable.data.MapList))...)
+	}
+	if current.MapFilter != "" && !slices.Contains(maps, current.MapFilter) {
+		maps = append(maps, current.MapFilter)
+	}
+	sides := []string{allOption, "T", "CT"}
+	columns := append([]string{nameOption}, LeaderboardMetrics()...)
+
+	form := tview.NewForm()
+	form.SetBorder(true).SetTitle("Save View").SetTitleAlign(tview.AlignLeft)
+	form.AddInputField(viewNameLabel, "", 30, nil, nil)
+	form.AddDropDown(viewMapLabel, maps, optionIndex(maps, current.MapFilter), nil)
+	form.AddDropDown(viewSideLabel, sides, optionIndex(sides, current.SideFilter), nil)
+	form.AddDropDown(viewSortLabel, columns, optionIndex(columns, current.SortColumn), nil)
+	form.AddCheckbox(viewDescLabel, current.SortDesc, nil)
+
+	closeForm := func() {
+		u.Pages.RemovePage(saveViewPage)
+		u.App.SetFocus(u.viewSelect)
+	}
+
+	form.AddButton("Save", func() {
+		preset := viewPresetFromForm(form)
+		if preset.Name == "" {
+			u.logEvent("Error: A view needs a name")
+			return
+		}
+		closeForm()
+		u.statsTable.ApplyView(preset)
+
+		if i := slices.IndexFunc(u.config.ViewPresets, func(p ViewPreset) bool { return p.Name == preset.Name }); i >= 0 {
+			u.config.ViewPresets[i] = preset
+		} else {
+			u.config.ViewPresets = append(u.config.ViewPresets, preset)
+		}
+		u.updateViewSelect(preset.Name)
+		if err := SaveConfig(u.config); err != nil {
+			u.logEvent(fmt.Sprintf("Warning: could not save config: %v", err))
+			return
+		}
+		u.logEvent(fmt.Sprintf("Saved view %q", preset.Name))
+	})
+	form.AddButton("Cancel", closeForm)
+	form.SetCancelFunc(closeForm)
+
+	u.Pages.AddPage(saveViewPage, centered(form, 50, 15), true, true)
+}
+
+// viewPresetFromForm reads a preset from the Save View form. "All" and
+// "Name" are stored as empty fields.
+func viewPresetFromForm(form *tview.Form) ViewPreset {
+	var preset ViewPreset
+	if field, ok := form.GetFormItemByLabel(viewNameLabel).(*tview.InputField); ok {
+		preset.Name = field.GetText()
+	}
+	if dropDown, ok := form.GetFormItemByLabel(viewMapLabel).(*tview.DropDown); ok {
+		if _, option := dropDown.GetCurrentOption(); option != allOption {
+			preset.MapFilter = option
+		}
+	}
+	if dropDown, ok := form.GetFormItemByLabel(viewSideLabel).(*tview.DropDown); ok {
+		if _, option := dropDown.GetCurrentOption(); option != allOption {
+			preset.SideFilter = option
+		}
+	}
+	if dropDown, ok := form.GetFormItemByLabel(viewSortLabel).(*tview.DropDown); ok {
+		if _, option := dropDown.GetCurrentOption(); option != nameOption {
+			preset.SortColumn = option
+		}
+	}
+	if checkbox, ok := form.GetFormItemByLabel(viewDescLabel).(*tview.Checkbox); ok {
+		preset.SortDesc = checkbox.IsChecked()
+	}
+	return preset
+}
+
+// optionIndex returns the index of value in options, or 0 (the "All" or
+// "Name" option) if it isn't there.
+func optionIndex(options []string, value string) int {
+	if i := slices.Index(options, value); i >= 0 {
+		return i
+	}
+	return 0
+}
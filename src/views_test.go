@@ -0,0 +1,40 @@
+package manalyzer
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestViewPresetsRoundTrip(t *testing.T) {
+	useTestHome(t)
+	presets := []ViewPreset{
+		{Name: "CT on Mirage by ADR", MapFilter: "de_mirage", SideFilter: "CT", SortColumn: "adr", SortDesc: true},
+		{Name: "T sides", SideFilter: "T"},
+	}
+	cfg := DefaultConfig()
+	cfg.ViewPresets = presets
+	cfg.LastView = &presets[1]
+	if err := SaveConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(loaded.ViewPresets, presets) {
+		t.Errorf("loaded presets %+v, want %+v", loaded.ViewPresets, presets)
+	}
+	if loaded.LastView == nil || *loaded.LastView != presets[1] {
+		t.Errorf("loaded last view %+v, want %+v", loaded.LastView, presets[1])
+	}
+
+	// Applying a preset and reading the view back gives the same preset
+	st := newStatisticsTable()
+	for _, preset := range loaded.ViewPresets {
+		st.ApplyView(preset)
+		if got := st.currentView(preset.Name); got != preset {
+			t.Errorf("table view %+v after applying %+v", got, preset)
+		}
+	}
+}
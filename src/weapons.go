@@ -0,0 +1,71 @@
+package manalyzer
+
+import (
+	"github.com/akiver/cs-demo-analyzer/pkg/api/constants"
+)
+
+// Weapon categories used by PlayerStats.WeaponCategoryKills.
+const (
+	WeaponCategoryRifle   = "rifle"
+	WeaponCategorySniper  = "sniper"
+	WeaponCategoryPistol  = "pistol"
+	WeaponCategorySMG     = "smg"
+	WeaponCategoryShotgun = "shotgun"
+	WeaponCategoryUtility = "util"
+	WeaponCategoryOther   = "other"
+)
+
+var weaponCategories = map[constants.WeaponName]string{
+	constants.WeaponAK47:    WeaponCategoryRifle,
+	constants.WeaponAUG:     WeaponCategoryRifle,
+	constants.WeaponFamas:   WeaponCategoryRifle,
+	constants.WeaponGalilAR: WeaponCategoryRifle,
+	constants.WeaponM4A1:    WeaponCategoryRifle,
+	constants.WeaponM4A4:    WeaponCategoryRifle,
+	constants.WeaponSG553:   WeaponCategoryRifle,
+
+	constants.WeaponAWP:    WeaponCategorySniper,
+	constants.WeaponScout:  WeaponCategorySniper,
+	constants.WeaponG3SG1:  WeaponCategorySniper,
+	constants.WeaponScar20: WeaponCategorySniper,
+
+	constants.WeaponCZ75:         WeaponCategoryPistol,
+	constants.WeaponDeagle:       WeaponCategoryPistol,
+	constants.WeaponDualBerettas: WeaponCategoryPistol,
+	constants.WeaponFiveSeven:    WeaponCategoryPistol,
+	constants.WeaponGlock:        WeaponCategoryPistol,
+	constants.WeaponP2000:        WeaponCategoryPistol,
+	constants.WeaponP250:         WeaponCategoryPistol,
+	constants.WeaponRevolver:     WeaponCategoryPistol,
+	constants.WeaponTec9:         WeaponCategoryPistol,
+	constants.WeaponUSP:          WeaponCategoryPistol,
+
+	constants.WeaponMac10:   WeaponCategorySMG,
+	constants.WeaponMP5:     WeaponCategorySMG,
+	constants.WeaponMP7:     WeaponCategorySMG,
+	constants.WeaponMP9:     WeaponCategorySMG,
+	constants.WeaponP90:     WeaponCategorySMG,
+	constants.WeaponPPBizon: WeaponCategorySMG,
+	constants.WeaponUMP45:   WeaponCategorySMG,
+
+	constants.WeaponMAG7:     WeaponCategoryShotgun,
+	constants.WeaponNova:     WeaponCategoryShotgun,
+	constants.WeaponSawedOff: WeaponCategoryShotgun,
+	constants.WeaponXM1014:   WeaponCategoryShotgun,
+
+	constants.WeaponHEGrenade:  WeaponCategoryUtility,
+	constants.WeaponMolotov:    WeaponCategoryUtility,
+	constants.WeaponIncendiary: WeaponCategoryUtility,
+	constants.WeaponFlashbang:  WeaponCategoryUtility,
+	constants.WeaponSmoke:      WeaponCategoryUtility,
+	constants.WeaponDecoy:      WeaponCategoryUtility,
+}
+
+// classifyWeapon returns the category of a weapon, or WeaponCategoryOther for
+// weapons not in the table (knife, Zeus, machine guns, world damage).
+func classifyWeapon(weapon constants.WeaponName) string {
+	if category, ok := weaponCategories[weapon]; ok {
+		return category
+	}
+	return WeaponCategoryOther
+}
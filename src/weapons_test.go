@@ -0,0 +1,48 @@
+package manalyzer
+
+import (
+	"maps"
+	"testing"
+
+	"github.com/akiver/cs-demo-analyzer/pkg/api"
+	"github.com/akiver/cs-demo-analyzer/pkg/api/constants"
+)
+
+func TestClassifyWeapon(t *testing.T) {
+	tests := []struct {
+		weapon constants.WeaponName
+		want   string
+	}{
+		{constants.WeaponAK47, WeaponCategoryRifle},
+		{constants.WeaponM4A1, WeaponCategoryRifle},
+		{constants.WeaponAWP, WeaponCategorySniper},
+		{constants.WeaponGlock, WeaponCategoryPistol},
+		{constants.WeaponMP9, WeaponCategorySMG},
+		{constants.WeaponNova, WeaponCategoryShotgun},
+		{constants.WeaponHEGrenade, WeaponCategoryUtility},
+		{constants.WeaponKnife, WeaponCategoryOther},
+		{constants.WeaponZeus, WeaponCategoryOther},
+		{constants.WeaponNegev, WeaponCategoryOther},
+		{constants.WeaponWorld, WeaponCategoryOther},
+		{"", WeaponCategoryOther},
+	}
+	for _, tt := range tests {
+		if got := classifyWeapon(tt.weapon); got != tt.want {
+			t.Errorf("classifyWeapon(%q) = %q, want %q", tt.weapon, got, tt.want)
+		}
+	}
+}
+
+func TestWeaponCategoryKills(t *testing.T) {
+	match := newTestMatch("de_mirage", 3)
+	addKill(match, 1, 300, alice, carol).WeaponName = constants.WeaponAK47
+	addKill(match, 2, 300, alice, carol).WeaponName = constants.WeaponAWP
+	addKill(match, 3, 300, alice, carol).WeaponName = constants.WeaponM4A4
+	addKill(match, 3, 400, alice, bob).WeaponName = constants.WeaponAK47 // Team kill
+
+	got := testPlayerStats(t, processTestMatches(t, []*api.Match{match}, alice), alice).WeaponCategoryKills
+	want := map[string]int{WeaponCategoryRifle: 2, WeaponCategorySniper: 1}
+	if !maps.Equal(got, want) {
+		t.Errorf("WeaponCategoryKills = %v, want %v", got, want)
+	}
+}
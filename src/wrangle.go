@@ -1,75 +1,331 @@
 package manalyzer
 
 import (
+	"context"
 	"fmt"
+	"path"
+	"slices"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/akiver/cs-demo-analyzer/pkg/api"
 	"github.com/markus-wa/demoinfocs-golang/v4/pkg/demoinfocs/common"
+	"github.com/markus-wa/demoinfocs-golang/v4/pkg/demoinfocs/events"
 )
 
+// tradeWindowSeconds matches the trade delay cs-demo-analyzer uses for
+// IsTradeKill/IsTradeDeath.
+const tradeWindowSeconds = 5.0
+
+// defaultTickRate is assumed when a demo doesn't report its tick rate.
+const defaultTickRate = 64.0
+
+// Round phases used by SideStatistics.DamageByPhase and OpeningDuelsByPhase,
+// measured from the end of freeze time.
+const (
+	DamagePhaseEarly = "0-15s"
+	DamagePhaseMid   = "15-40s"
+	DamagePhaseLate  = "40s+"
+)
+
+// maxClutchOpponents is the largest clutch tracked, 1v5.
+const maxClutchOpponents = 5
+
+// Rounds per regulation half in MR12 (CS2) and MR15 (CS:GO) matches.
+const (
+	mr12HalfLength = 12
+	mr15HalfLength = 15
+)
+
+// rwsObjectiveShare is the part of a won round's 100 RWS points awarded to the
+// bomb planter or defuser when the round ends on the objective.
+const rwsObjectiveShare = 30.0
+
 // PlayerStats holds statistics for a player across all matches.
 type PlayerStats struct {
-	SteamID64    string
-	PlayerName   string
-	MapStats     map[string]*MapStatistics
-	OverallStats *OverallStatistics
+	SteamID64    string                    `json:"steamId64"`
+	PlayerName   string                    `json:"playerName"`
+	MapStats     map[string]*MapStatistics `json:"mapStats"`
+	OverallStats *OverallStatistics        `json:"overallStats,omitempty"`
+
+	// WeaponCategoryKills counts kills per weapon category (see classifyWeapon).
+	WeaponCategoryKills map[string]int `json:"weaponCategoryKills,omitempty"`
+
+	// WeaponKills counts kills per weapon, keyed by the demo's weapon name
+	// (e.g. "AK-47").
+	WeaponKills map[string]int `json:"weaponKills,omitempty"`
+
+	// DisplayName is PlayerName with a SteamID64 suffix when another tracked
+	// player has the same name, set by disambiguateNames, or the player's
+	// alias (see WithAliases). It is not saved, so exports and saved results
+	// keep the real name.
+	DisplayName string `json:"-"`
+}
+
+// ShownName returns the name to show for the player: DisplayName if set,
+// else PlayerName, else the SteamID64.
+func (p *PlayerStats) ShownName() string {
+	switch {
+	case p.DisplayName != "":
+		return p.DisplayName
+	case p.PlayerName != "":
+		return p.PlayerName
+	}
+	return p.SteamID64
 }
 
 // MapStatistics holds per-map statistics for a player.
 type MapStatistics struct {
-	MapName       string
-	MatchesPlayed int
-	SideStats     map[string]*SideStatistics // Keys: "T" and "CT"
+	MapName       string                     `json:"mapName"`
+	RawMapNames   []string                   `json:"rawMapNames,omitempty"` // Names as reported by the demos, before normalization
+	MatchesPlayed int                        `json:"matchesPlayed"`
+	SideStats     map[string]*SideStatistics `json:"sideStats"` // Keys: "T" and "CT"
+
+	// Per-match samples (both sides combined), kept for median aggregation.
+	ADRSamples  []float64 `json:"adrSamples,omitempty"`
+	KASTSamples []float64 `json:"kastSamples,omitempty"`
+
+	// Matches breaks the map's stats down by match, in input order.
+	Matches []*MatchStatistics `json:"matches,omitempty"`
+}
+
+// MatchStatistics holds a player's statistics for a single match,
+// identified by its MatchInfo.
+type MatchStatistics struct {
+	MatchInfo
+	SideStats map[string]*SideStatistics `json:"sideStats"` // Keys: "T" and "CT"
+}
+
+// Overall combines the match's sides like OverallStatistics.
+func (ms *MatchStatistics) Overall() *OverallStatistics {
+	mapStats := &MapStatistics{MapName: ms.MapName, MatchesPlayed: 1, SideStats: ms.SideStats}
+	return calculateOverallStats(map[string]*MapStatistics{ms.MapName: mapStats}, AverageModeMean)
 }
 
 // SideStatistics holds statistics for one side (T or CT) on a map.
 type SideStatistics struct {
-	Side         string
-	KAST         float64 // Percentage (0-100)
-	ADR          float64
-	KD           float64
-	Kills        int
-	Deaths       int
-	FirstKills   int
-	FirstDeaths  int
-	TradeKills   int
-	TradeDeaths  int
-	Assists      int
-	Headshots    int
-	RoundsPlayed int
+	Side         string  `json:"side"`
+	KAST         float64 `json:"kast"` // Percentage (0-100)
+	ADR          float64 `json:"adr"`
+	KD           float64 `json:"kd"`
+	Kills        int     `json:"kills"`
+	Deaths       int     `json:"deaths"`
+	FirstKills   int     `json:"firstKills"`
+	FirstDeaths  int     `json:"firstDeaths"`
+	TradeKills   int     `json:"tradeKills"`
+	TradeDeaths  int     `json:"tradeDeaths"`  // Deaths whose killer died within the trade window (any killer, per cs-demo-analyzer)
+	Assists      int     `json:"assists"`      // Damage and flash assists
+	FlashAssists int     `json:"flashAssists"` // Assists credited for a flashbang, also counted in Assists
+	Headshots    int     `json:"headshots"`
+	RoundsPlayed int     `json:"roundsPlayed"`
+	RWS          float64 `json:"rws"` // Round Win Share, average per round played
+
+	// KillDeathDiffPerRound is (Kills - Deaths) / RoundsPlayed, shown as +/-.
+	KillDeathDiffPerRound float64 `json:"killDeathDiffPerRound"`
+
+	// RoundsWon counts the rounds played that the player's team won, and
+	// RoundWinRate is its percentage of RoundsPlayed.
+	RoundsWon    int     `json:"roundsWon"`
+	RoundWinRate float64 `json:"roundWinRate"`
+
+	// Pistol rounds are the first round of each regulation half; see
+	// halfLength for how MR12 and MR15 are told apart.
+	PistolRoundsPlayed int     `json:"pistolRoundsPlayed"`
+	PistolRoundsWon    int     `json:"pistolRoundsWon"`
+	PistolRoundWinRate float64 `json:"pistolRoundWinRate"` // Percentage of PistolRoundsPlayed won
+
+	// HasDamageData is false when the demo had no damage events in the
+	// player's rounds on this side, so an ADR of 0 means "unknown" and the
+	// side is left out of combined ADR.
+	HasDamageData bool `json:"hasDamageData"`
+	// DamageRounds counts the rounds played in demos with damage data. ADR
+	// is the damage per such round, so combined ADR is weighted by it.
+	DamageRounds int `json:"damageRounds"`
+
+	// TimesTradedFor counts deaths avenged by a teammate: the player's killer
+	// was killed by one of the player's teammates within tradeWindowSeconds.
+	TimesTradedFor int `json:"timesTradedFor"`
+
+	FlashesThrown   int     `json:"flashesThrown"`
+	EnemiesFlashed  int     `json:"enemiesFlashed"`
+	FlashEfficiency float64 `json:"flashEfficiency"` // Enemies flashed per flashbang thrown
+
+	// UtilityDamage is the health damage dealt to enemies with grenades,
+	// HE and fire alike. It is also part of ADR.
+	UtilityDamage int `json:"utilityDamage"`
+
+	// KAST breakdown: rounds in which the player got a kill, an assist,
+	// survived or was traded. A round counts for every condition it meets,
+	// so these can sum to more than the KAST rounds.
+	KASTViaKill    int `json:"kastViaKill"`
+	KASTViaAssist  int `json:"kastViaAssist"`
+	KASTViaSurvive int `json:"kastViaSurvive"`
+	KASTViaTrade   int `json:"kastViaTrade"`
+
+	// DamageByPhase sums health damage by time into the round, keyed by
+	// DamagePhaseEarly/Mid/Late.
+	DamageByPhase map[string]int `json:"damageByPhase,omitempty"`
+
+	// OpeningDuelsByPhase counts the player's opening duels (FK + FD) by
+	// when in the round the opening kill happened, keyed like DamageByPhase.
+	OpeningDuelsByPhase map[string]int `json:"openingDuelsByPhase,omitempty"`
+
+	// Rounds won by the player's team after the player got the opening
+	// kill / died first.
+	FirstKillRoundsWon  int `json:"firstKillRoundsWon"`
+	FirstDeathRoundsWon int `json:"firstDeathRoundsWon"`
+
+	// OpeningDuelWinRate is the percentage of opening duels won,
+	// FirstKills / (FirstKills + FirstDeaths); 0 without opening duels.
+	OpeningDuelWinRate float64 `json:"openingDuelWinRate"`
+
+	// ClutchAttempts counts rounds in which the player was the last one
+	// alive on their side, indexed by enemies left minus one (0 is 1v1, 4 is
+	// 1v5). ClutchWins counts those rounds the player's team went on to win.
+	ClutchAttempts [maxClutchOpponents]int `json:"clutchAttempts"`
+	ClutchWins     [maxClutchOpponents]int `json:"clutchWins"`
+
+	// Rounds in which the player got exactly 2, 3, 4 or 5 (an ace) kills,
+	// counting only kills that add to Kills.
+	Kills2 int `json:"kills2"`
+	Kills3 int `json:"kills3"`
+	Kills4 int `json:"kills4"`
+	Kills5 int `json:"kills5"`
 }
 
 // OverallStatistics holds aggregated stats across all maps and sides.
 type OverallStatistics struct {
-	KAST          float64
-	ADR           float64
-	KD            float64
-	Kills         int
-	Deaths        int
-	FirstKills    int
-	FirstDeaths   int
-	TradeKills    int
-	TradeDeaths   int
-	Assists       int
-	Headshots     int
-	RoundsPlayed  int
-	MatchesPlayed int
+	KAST           float64 `json:"kast"`
+	ADR            float64 `json:"adr"`
+	KD             float64 `json:"kd"`
+	RWS            float64 `json:"rws"`
+	Kills          int     `json:"kills"`
+	Deaths         int     `json:"deaths"`
+	FirstKills     int     `json:"firstKills"`
+	FirstDeaths    int     `json:"firstDeaths"`
+	TradeKills     int     `json:"tradeKills"`
+	TradeDeaths    int     `json:"tradeDeaths"`
+	TimesTradedFor int     `json:"timesTradedFor"`
+	Assists        int     `json:"assists"`
+	FlashAssists   int     `json:"flashAssists"`
+	Headshots      int     `json:"headshots"`
+	RoundsPlayed   int     `json:"roundsPlayed"`
+	MatchesPlayed  int     `json:"matchesPlayed"`
+
+	FlashesThrown   int     `json:"flashesThrown"`
+	EnemiesFlashed  int     `json:"enemiesFlashed"`
+	FlashEfficiency float64 `json:"flashEfficiency"`
+	UtilityDamage   int     `json:"utilityDamage"`
+
+	FirstKillRoundsWon       int     `json:"firstKillRoundsWon"`
+	FirstDeathRoundsWon      int     `json:"firstDeathRoundsWon"`
+	OpeningKillRoundWinRate  float64 `json:"openingKillRoundWinRate"`  // Percentage of rounds won after an opening kill
+	OpeningDeathRoundWinRate float64 `json:"openingDeathRoundWinRate"` // Percentage of rounds won after dying first
+	OpeningDuelWinRate       float64 `json:"openingDuelWinRate"`       // Percentage of opening duels won
+
+	KillDeathDiffPerRound float64 `json:"killDeathDiffPerRound"` // (Kills - Deaths) / RoundsPlayed
+
+	RoundsWon          int     `json:"roundsWon"`
+	RoundWinRate       float64 `json:"roundWinRate"` // Percentage of RoundsPlayed won
+	PistolRoundsPlayed int     `json:"pistolRoundsPlayed"`
+	PistolRoundsWon    int     `json:"pistolRoundsWon"`
+	PistolRoundWinRate float64 `json:"pistolRoundWinRate"` // Percentage of PistolRoundsPlayed won
+
+	// Clutches of every size (1v1 to 1v5) combined
+	ClutchAttempts int     `json:"clutchAttempts"`
+	ClutchWins     int     `json:"clutchWins"`
+	ClutchWinRate  float64 `json:"clutchWinRate"` // Percentage of ClutchAttempts won
+
+	Kills2 int `json:"kills2"`
+	Kills3 int `json:"kills3"`
+	Kills4 int `json:"kills4"`
+	Kills5 int `json:"kills5"` // Aces
 }
 
 // WrangleResult is the output of ProcessMatches.
 type WrangleResult struct {
-	PlayerStats  []*PlayerStats
-	MapList      []string
-	TotalMatches int
+	PlayerStats  []*PlayerStats `json:"playerStats"`
+	MapList      []string       `json:"mapList"`
+	TotalMatches int            `json:"totalMatches"`
+
+	// Matches describes each analyzed match, in input order.
+	Matches []MatchInfo `json:"matches,omitempty"`
+
+	// TopFraggerByMap names the tracked player with the best K/D on each
+	// map; tied players are joined with ", ".
+	TopFraggerByMap map[string]string `json:"topFraggerByMap,omitempty"`
+
+	// PairSynergy holds results for each pair of tracked players in rounds
+	// they played on the same side, keyed "<lower SteamID64>+<higher>".
+	PairSynergy map[string]*PairSynergy `json:"pairSynergy,omitempty"`
+
+	// TeamStats combines every tracked player's stats as if they were one
+	// player, so KAST and ADR are averaged over all of their rounds. Nil
+	// when only one player is tracked.
+	TeamStats *OverallStatistics `json:"teamStats,omitempty"`
+
+	// Diagnostics are per-match notes worth showing the user, such as a
+	// tracked player who only spectated a demo.
+	Diagnostics []string `json:"diagnostics,omitempty"`
 }
 
-// determinePlayerSideInRound returns which side (T or CT) a player was on.
+// WithAliases returns a copy of r in which players with an alias, keyed by
+// SteamID64, are shown by it, e.g. in charts. r itself is left untouched.
+func (r *WrangleResult) WithAliases(aliases map[string]string) *WrangleResult {
+	if r == nil || len(aliases) == 0 {
+		return r
+	}
+	aliased := *r
+	aliased.PlayerStats = make([]*PlayerStats, len(r.PlayerStats))
+	for i, playerStats := range r.PlayerStats {
+		aliased.PlayerStats[i] = playerStats
+		if playerStats == nil {
+			continue
+		}
+		if alias := aliases[playerStats.SteamID64]; alias != "" {
+			renamed := *playerStats
+			renamed.DisplayName = alias
+			aliased.PlayerStats[i] = &renamed
+		}
+	}
+	return &aliased
+}
+
+// determinePlayerSideInRound returns which side (T or CT) a player was on in
+// round. The side comes from the player's economy entry for the round, which
+// cs-demo-analyzer records for everyone playing it, so a player who
+// spectated some rounds or switched teams is placed correctly in each. For
+// players without economy data the side follows their team. Players on
+// neither team (spectators, coaches, or a nil team) get
+// common.TeamUnassigned, so callers skip the round for them.
 func determinePlayerSideInRound(match *api.Match, player *api.Player, round *api.Round) common.Team {
+	hasEconomy := false
+	for _, economy := range match.PlayerEconomies {
+		if economy.SteamID64 != player.SteamID64 {
+			continue
+		}
+		if economy.RoundNumber == round.Number {
+			return economy.PlayerSide
+		}
+		hasEconomy = true
+	}
+	if hasEconomy {
+		// Playing other rounds but not this one
+		return common.TeamUnassigned
+	}
+
+	if player.Team == nil {
+		return common.TeamUnassigned
+	}
 	if player.Team == match.TeamA {
 		return round.TeamASide
 	}
-	return round.TeamBSide
+	if player.Team == match.TeamB {
+		return round.TeamBSide
+	}
+	return common.TeamUnassigned
 }
 
 // sideToString converts common.Team to "T" or "CT" string.
@@ -83,8 +339,77 @@ func sideToString(side common.Team) string {
 	return ""
 }
 
-// ProcessMatches processes demo matches and extracts player statistics.
-func ProcessMatches(matches []*api.Match, steamIDs []string) (*WrangleResult, error) {
+// normalizeMapName maps demo map names to a common key so that variants of
+// the same map share a bucket: "workshop/123456/de_mirage" and "DE_Mirage"
+// both become "de_mirage".
+func normalizeMapName(rawName string) string {
+	name := strings.ToLower(strings.TrimSpace(rawName))
+	name = strings.ReplaceAll(name, "\\", "/")
+	name = path.Base(name)
+	name = strings.TrimSuffix(name, ".bsp")
+	if name == "." || name == "/" {
+		return rawName
+	}
+	return name
+}
+
+// FilterMatchesByRoster keeps the matches in which at least minPresent of
+// steamIDs played, and returns how many were dropped. minPresent <= 0 keeps
+// every match. Invalid SteamIDs never count as present.
+func FilterMatchesByRoster(matches []*api.Match, steamIDs []string, minPresent int) ([]*api.Match, int) {
+	if minPresent <= 0 {
+		return matches, 0
+	}
+
+	ids := make([]uint64, 0, len(steamIDs))
+	for _, steamIDStr := range steamIDs {
+		if steamID64, err := strconv.ParseUint(steamIDStr, 10, 64); err == nil {
+			ids = append(ids, steamID64)
+		}
+	}
+
+	kept := make([]*api.Match, 0, len(matches))
+	for _, match := range matches {
+		present := 0
+		for _, steamID64 := range ids {
+			if _, ok := match.PlayersBySteamID[steamID64]; ok {
+				present++
+			}
+		}
+		if present >= minPresent {
+			kept = append(kept, match)
+		}
+	}
+	return kept, len(matches) - len(kept)
+}
+
+// FilterMatchesByDate keeps the matches dated from from through the whole day
+// of to, and returns how many were dropped. A zero from or to leaves that end
+// open. The date is the match time recorded in the demo, or the file's
+// modification time when the demo has none.
+func FilterMatchesByDate(matches []*api.Match, from, to time.Time) ([]*api.Match, int) {
+	if from.IsZero() && to.IsZero() {
+		return matches, 0
+	}
+
+	kept := make([]*api.Match, 0, len(matches))
+	for _, match := range matches {
+		if !from.IsZero() && match.Date.Before(from) {
+			continue
+		}
+		if !to.IsZero() && !match.Date.Before(to.AddDate(0, 0, 1)) {
+			continue
+		}
+		kept = append(kept, match)
+	}
+	return kept, len(matches) - len(kept)
+}
+
+// ProcessMatches aggregates stats for steamIDs across matches. A non-zero
+// roundRange limits every stat, and the matches passed to analyzers, to the
+// rounds within it. If ctx is done before the last match, ctx's error is
+// returned.
+func ProcessMatches(ctx context.Context, matches []*api.Match, steamIDs []string, prefs Preferences, roundRange [2]int) (*WrangleResult, error) {
 	if len(matches) == 0 {
 		return nil, fmt.Errorf("no matches to process")
 	}
@@ -109,18 +434,48 @@ func ProcessMatches(matches []*api.Match, steamIDs []string) (*WrangleResult, er
 	playerStatsMap := make(map[uint64]*PlayerStats)
 	for _, steamID64 := range steamID64s {
 		playerStatsMap[steamID64] = &PlayerStats{
-			SteamID64: strconv.FormatUint(steamID64, 10),
-			MapStats:  make(map[string]*MapStatistics),
+			SteamID64:           strconv.FormatUint(steamID64, 10),
+			MapStats:            make(map[string]*MapStatistics),
+			WeaponCategoryKills: make(map[string]int),
+			WeaponKills:         make(map[string]int),
 		}
 	}
 
+	excluded := make(map[uint64]bool, len(prefs.ExcludeSteamIDs))
+	for _, steamIDStr := range prefs.ExcludeSteamIDs {
+		steamID64, err := strconv.ParseUint(steamIDStr, 10, 64)
+		if err != nil {
+			LogWarn("Ignoring invalid excluded SteamID64 %q: %v", steamIDStr, err)
+			continue
+		}
+		excluded[steamID64] = true
+	}
+
 	mapsEncountered := make(map[string]bool)
+	pairs := make(map[string]*PairSynergy)
+	var diagnostics []string
+	ownKills := make(map[uint64]int) // Kill events as killer, victim or assister
+	botKills := make(map[uint64]int) // The same while controlling a bot
 
 	for _, match := range matches {
-		mapName := match.MapName
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		match = excludeAccounts(restrictToRounds(match, roundRange), excluded)
+		if !prefs.CountFlashAssists {
+			match = withoutFlashAssists(match)
+		}
+		if roundRange != [2]int{} && len(match.Rounds) == 0 {
+			continue // e.g. rounds 16-30 of a match that ended 13-2
+		}
+		mapName := normalizeMapName(match.MapName)
 		mapsEncountered[mapName] = true
 
 		for steamID64, playerStats := range playerStatsMap {
+			own, asBot := killInvolvement(match, steamID64)
+			ownKills[steamID64] += own
+			botKills[steamID64] += asBot
+
 			player, exists := match.PlayersBySteamID[steamID64]
 			if !exists {
 				continue
@@ -130,6 +485,12 @@ func ProcessMatches(matches []*api.Match, steamIDs []string) (*WrangleResult, er
 				playerStats.PlayerName = player.Name
 			}
 
+			// Spectators and casters are in the match but never on a side
+			if !playedAnyRound(match, player) {
+				diagnostics = append(diagnostics, fmt.Sprintf("%s spectated %s", player.Name, match.DemoFileName))
+				continue
+			}
+
 			if playerStats.MapStats[mapName] == nil {
 				playerStats.MapStats[mapName] = &MapStatistics{
 					MapName:       mapName,
@@ -140,12 +501,31 @@ func ProcessMatches(matches []*api.Match, steamIDs []string) (*WrangleResult, er
 
 			mapStats := playerStats.MapStats[mapName]
 			mapStats.MatchesPlayed++
+			if !slices.Contains(mapStats.RawMapNames, match.MapName) {
+				mapStats.RawMapNames = append(mapStats.RawMapNames, match.MapName)
+			}
 
 			sideStatsFromMatch := extractPlayerStatsBySide(match, player)
+			countWeaponKills(match, player, playerStats.WeaponKills, playerStats.WeaponCategoryKills)
+			mapStats.Matches = append(mapStats.Matches, &MatchStatistics{
+				MatchInfo: NewMatchInfo(match),
+				SideStats: sideStatsFromMatch,
+			})
+
+			if adr, kast, hasADR, ok := matchAverages(sideStatsFromMatch); ok {
+				if hasADR {
+					mapStats.ADRSamples = append(mapStats.ADRSamples, adr)
+				}
+				mapStats.KASTSamples = append(mapStats.KASTSamples, kast)
+			}
 
 			for sideKey, newStats := range sideStatsFromMatch {
 				if mapStats.SideStats[sideKey] == nil {
-					mapStats.SideStats[sideKey] = &SideStatistics{Side: sideKey}
+					mapStats.SideStats[sideKey] = &SideStatistics{
+						Side:                sideKey,
+						DamageByPhase:       make(map[string]int),
+						OpeningDuelsByPhase: make(map[string]int),
+					}
 				}
 
 				existing := mapStats.SideStats[sideKey]
@@ -153,20 +533,54 @@ func ProcessMatches(matches []*api.Match, steamIDs []string) (*WrangleResult, er
 				existing.Kills += newStats.Kills
 				existing.Deaths += newStats.Deaths
 				existing.Assists += newStats.Assists
+				existing.FlashAssists += newStats.FlashAssists
 				existing.FirstKills += newStats.FirstKills
 				existing.FirstDeaths += newStats.FirstDeaths
 				existing.TradeKills += newStats.TradeKills
 				existing.TradeDeaths += newStats.TradeDeaths
+				existing.TimesTradedFor += newStats.TimesTradedFor
+				existing.FlashesThrown += newStats.FlashesThrown
+				existing.EnemiesFlashed += newStats.EnemiesFlashed
+				existing.FlashEfficiency = flashEfficiency(existing.EnemiesFlashed, existing.FlashesThrown)
+				existing.UtilityDamage += newStats.UtilityDamage
+				existing.KASTViaKill += newStats.KASTViaKill
+				existing.KASTViaAssist += newStats.KASTViaAssist
+				existing.KASTViaSurvive += newStats.KASTViaSurvive
+				existing.KASTViaTrade += newStats.KASTViaTrade
+				for phase, damage := range newStats.DamageByPhase {
+					existing.DamageByPhase[phase] += damage
+				}
+				for phase, duels := range newStats.OpeningDuelsByPhase {
+					existing.OpeningDuelsByPhase[phase] += duels
+				}
 				existing.Headshots += newStats.Headshots
+				existing.HasDamageData = existing.HasDamageData || newStats.HasDamageData
+				existing.FirstKillRoundsWon += newStats.FirstKillRoundsWon
+				existing.FirstDeathRoundsWon += newStats.FirstDeathRoundsWon
+				existing.Kills2 += newStats.Kills2
+				existing.Kills3 += newStats.Kills3
+				existing.Kills4 += newStats.Kills4
+				existing.Kills5 += newStats.Kills5
+				existing.RoundsWon += newStats.RoundsWon
+				existing.PistolRoundsPlayed += newStats.PistolRoundsPlayed
+				existing.PistolRoundsWon += newStats.PistolRoundsWon
+				for i := range existing.ClutchAttempts {
+					existing.ClutchAttempts[i] += newStats.ClutchAttempts[i]
+					existing.ClutchWins[i] += newStats.ClutchWins[i]
+				}
 
 				oldRounds := existing.RoundsPlayed
 				newRounds := newStats.RoundsPlayed
 				existing.RoundsPlayed += newRounds
 
-				if existing.RoundsPlayed > 0 {
-					oldDamage := existing.ADR * float64(oldRounds)
-					newDamage := newStats.ADR * float64(newRounds)
-					existing.ADR = (oldDamage + newDamage) / float64(existing.RoundsPlayed)
+				// Weighted by rounds with damage data, so a match without
+				// any doesn't dilute ADR
+				oldDamageRounds := existing.DamageRounds
+				existing.DamageRounds += newStats.DamageRounds
+				if existing.DamageRounds > 0 {
+					oldDamage := existing.ADR * float64(oldDamageRounds)
+					newDamage := newStats.ADR * float64(newStats.DamageRounds)
+					existing.ADR = (oldDamage + newDamage) / float64(existing.DamageRounds)
 				}
 
 				// Weighted average for KAST
@@ -176,24 +590,53 @@ func ProcessMatches(matches []*api.Match, steamIDs []string) (*WrangleResult, er
 					existing.KAST = ((oldKAST + newKAST) / float64(existing.RoundsPlayed)) * 100.0
 				}
 
+				// Weighted average for RWS
+				if existing.RoundsPlayed > 0 {
+					oldRWS := existing.RWS * float64(oldRounds)
+					newRWS := newStats.RWS * float64(newRounds)
+					existing.RWS = (oldRWS + newRWS) / float64(existing.RoundsPlayed)
+				}
+
 				// Recalculate K/D
 				if existing.Deaths > 0 {
 					existing.KD = float64(existing.Kills) / float64(existing.Deaths)
 				} else if existing.Kills > 0 {
 					existing.KD = float64(existing.Kills)
 				}
+				existing.KillDeathDiffPerRound = killDeathDiffPerRound(existing.Kills, existing.Deaths, existing.RoundsPlayed)
+				existing.OpeningDuelWinRate = percentage(existing.FirstKills, existing.FirstKills+existing.FirstDeaths)
+				existing.RoundWinRate = percentage(existing.RoundsWon, existing.RoundsPlayed)
+				existing.PistolRoundWinRate = percentage(existing.PistolRoundsWon, existing.PistolRoundsPlayed)
 			}
 		}
+
+		accumulatePairSynergy(match, steamID64s, pairs)
+		runMatchAnalyzers(match, playerStatsMap)
+	}
+	finishPairSynergy(pairs)
+
+	// Stats skip bot-controlled actions, so such an ID ends up silently empty
+	for _, steamID64 := range steamID64s {
+		if botKills[steamID64] > 0 && ownKills[steamID64] == 0 {
+			diagnostics = append(diagnostics, fmt.Sprintf(
+				"SteamID64 %d only appears controlling a bot, it is likely a bot or disconnected slot", steamID64))
+		}
 	}
 
 	for _, playerStats := range playerStatsMap {
-		playerStats.OverallStats = calculateOverallStats(playerStats.MapStats)
+		playerStats.OverallStats = calculateOverallStats(playerStats.MapStats, prefs.AverageMode)
 	}
 
 	playerStatsList := make([]*PlayerStats, 0, len(playerStatsMap))
 	for _, stats := range playerStatsMap {
 		playerStatsList = append(playerStatsList, stats)
 	}
+	disambiguateNames(playerStatsList)
+
+	matchInfos := make([]MatchInfo, 0, len(matches))
+	for _, match := range matches {
+		matchInfos = append(matchInfos, NewMatchInfo(match))
+	}
 
 	mapList := make([]string, 0, len(mapsEncountered))
 	for mapName := range mapsEncountered {
@@ -201,35 +644,56 @@ func ProcessMatches(matches []*api.Match, steamIDs []string) (*WrangleResult, er
 	}
 
 	return &WrangleResult{
-		PlayerStats:  playerStatsList,
-		MapList:      mapList,
-		TotalMatches: len(matches),
+		PlayerStats:     playerStatsList,
+		MapList:         mapList,
+		TotalMatches:    len(matches),
+		Matches:         matchInfos,
+		PairSynergy:     pairs,
+		TeamStats:       calculateTeamStats(playerStatsList, prefs.AverageMode),
+		TopFraggerByMap: topFraggersByMap(playerStatsList),
+		Diagnostics:     diagnostics,
 	}, nil
 }
 
 // extractPlayerStatsBySide extracts side-specific statistics for a player from a match.
 func extractPlayerStatsBySide(match *api.Match, player *api.Player) map[string]*SideStatistics {
 	sideStats := make(map[string]*SideStatistics)
-	sideStats["T"] = &SideStatistics{Side: "T"}
-	sideStats["CT"] = &SideStatistics{Side: "CT"}
+	for _, side := range []string{"T", "CT"} {
+		sideStats[side] = &SideStatistics{
+			Side:                side,
+			DamageByPhase:       make(map[string]int),
+			OpeningDuelsByPhase: make(map[string]int),
+		}
+	}
 
+	half := halfLength(match)
 	for _, round := range match.Rounds {
 		playerSide := determinePlayerSideInRound(match, player, round)
 		sideKey := sideToString(playerSide)
 		if sideKey == "" {
 			continue
 		}
-		sideStats[sideKey].RoundsPlayed++
+		stats := sideStats[sideKey]
+		stats.RoundsPlayed++
+		won := round.WinnerSide == playerSide
+		if won {
+			stats.RoundsWon++
+		}
+		if round.OvertimeNumber == 0 && (round.Number == 1 || round.Number == half+1) {
+			stats.PistolRoundsPlayed++
+			if won {
+				stats.PistolRoundsWon++
+			}
+		}
+	}
+	for _, stats := range sideStats {
+		stats.RoundWinRate = percentage(stats.RoundsWon, stats.RoundsPlayed)
+		stats.PistolRoundWinRate = percentage(stats.PistolRoundsWon, stats.PistolRoundsPlayed)
 	}
 
+	killsByRound := make(map[*api.Round]int)
 	for _, kill := range match.Kills {
-		var round *api.Round
-		for _, r := range match.Rounds {
-			if r.Number == kill.RoundNumber {
-				round = r
-				break
-			}
-		}
+		round := roundByNumber(match, kill.RoundNumber)
 		if round == nil {
 			continue
 		}
@@ -243,8 +707,9 @@ func extractPlayerStatsBySide(match *api.Match, player *api.Player) map[string]*
 
 		// Count kills (if player is killer)
 		if kill.KillerSteamID64 == player.SteamID64 && !kill.IsKillerControllingBot {
-			if !kill.IsSuicide() && !kill.IsTeamKill() {
+			if isValidFrag(kill) {
 				stats.Kills++
+				killsByRound[round]++
 				if kill.IsHeadshot {
 					stats.Headshots++
 				}
@@ -260,16 +725,34 @@ func extractPlayerStatsBySide(match *api.Match, player *api.Player) map[string]*
 				if kill.IsTradeDeath {
 					stats.TradeDeaths++
 				}
+				if wasTradedByTeammate(match, kill) {
+					stats.TimesTradedFor++
+				}
 			}
 		}
 
-		if kill.AssisterSteamID64 == player.SteamID64 && !kill.IsAssisterControllingBot {
-			if kill.AssisterSide != kill.VictimSide {
-				stats.Assists++
+		if isValidAssist(kill, player) {
+			stats.Assists++
+			if kill.IsAssistedFlash {
+				stats.FlashAssists++
 			}
 		}
 	}
 
+	for round, kills := range killsByRound {
+		stats := sideStats[sideToString(determinePlayerSideInRound(match, player, round))]
+		switch kills {
+		case 2:
+			stats.Kills2++
+		case 3:
+			stats.Kills3++
+		case 4:
+			stats.Kills4++
+		case 5:
+			stats.Kills5++
+		}
+	}
+
 	for _, round := range match.Rounds {
 		playerSide := determinePlayerSideInRound(match, player, round)
 		sideKey := sideToString(playerSide)
@@ -285,45 +768,62 @@ func extractPlayerStatsBySide(match *api.Match, player *api.Player) map[string]*
 			}
 		}
 
-		// Find first kill
-		for _, kill := range killsInRound {
-			if kill.IsKillerControllingBot || kill.IsSuicide() || kill.IsTeamKill() {
-				continue
-			}
-			if kill.KillerSteamID64 == player.SteamID64 {
-				stats.FirstKills++
-			}
-			break
+		opening := openingKill(killsInRound)
+		if opening == nil {
+			continue
 		}
 
-		for _, kill := range killsInRound {
-			if kill.IsVictimControllingBot || kill.IsSuicide() || kill.IsTeamKill() {
-				continue
+		if opening.KillerSteamID64 == player.SteamID64 && !opening.IsKillerControllingBot {
+			stats.FirstKills++
+			if round.WinnerSide == playerSide {
+				stats.FirstKillRoundsWon++
 			}
-			if kill.VictimSteamID64 == player.SteamID64 {
-				stats.FirstDeaths++
+		}
+
+		if opening.VictimSteamID64 == player.SteamID64 && !opening.IsVictimControllingBot {
+			stats.FirstDeaths++
+			if round.WinnerSide == playerSide {
+				stats.FirstDeathRoundsWon++
 			}
-			break
+		}
+
+		tookDuel := (opening.KillerSteamID64 == player.SteamID64 && !opening.IsKillerControllingBot) ||
+			(opening.VictimSteamID64 == player.SteamID64 && !opening.IsVictimControllingBot)
+		if tookDuel {
+			stats.OpeningDuelsByPhase[damagePhase(match, round, opening.Tick)]++
 		}
 	}
 
 	totalDamagePerSide := make(map[string]int)
+	unattributedDamage := 0
 	for _, damage := range match.Damages {
-		if damage.AttackerSteamID64 != player.SteamID64 {
+		round := damageRound(match, damage)
+		if round == nil {
+			if damage.AttackerSteamID64 == player.SteamID64 {
+				unattributedDamage += damage.HealthDamage
+			}
 			continue
 		}
-
-		for _, round := range match.Rounds {
-			if damage.Tick >= round.StartTick && damage.Tick <= round.EndTick {
-				playerSide := determinePlayerSideInRound(match, player, round)
-				sideKey := sideToString(playerSide)
-				if sideKey != "" {
-					totalDamagePerSide[sideKey] += damage.HealthDamage
-				}
-				break
+		playerSide := determinePlayerSideInRound(match, player, round)
+		sideKey := sideToString(playerSide)
+		if sideKey == "" {
+			continue
+		}
+		// Any damage in the player's rounds shows the demo has damage data
+		sideStats[sideKey].HasDamageData = true
+		if damage.AttackerSteamID64 == player.SteamID64 {
+			totalDamagePerSide[sideKey] += damage.HealthDamage
+			phase := damagePhase(match, round, damage.Tick)
+			sideStats[sideKey].DamageByPhase[phase] += damage.HealthDamage
+			if damage.IsGrenadeWeapon() && damage.VictimSide != damage.AttackerSide {
+				sideStats[sideKey].UtilityDamage += damage.HealthDamage
 			}
 		}
 	}
+	if unattributedDamage > 0 {
+		LogWarn("%d damage by %s in %s matched no round, left out of ADR",
+			unattributedDamage, player.Name, match.DemoFileName)
+	}
 
 	for sideKey, totalDamage := range totalDamagePerSide {
 		if sideKey == "" {
@@ -333,26 +833,367 @@ func extractPlayerStatsBySide(match *api.Match, player *api.Player) map[string]*
 			stats.ADR = float64(totalDamage) / float64(stats.RoundsPlayed)
 		}
 	}
+	for _, stats := range sideStats {
+		if stats.HasDamageData {
+			stats.DamageRounds = stats.RoundsPlayed
+		}
+	}
+
+	for _, flash := range match.FlashbangsExplode {
+		if flash.ThrowerSteamID64 != player.SteamID64 {
+			continue
+		}
+		if stats, ok := sideStats[sideToString(flash.ThrowerSide)]; ok {
+			stats.FlashesThrown++
+		}
+	}
+
+	for _, flashed := range match.PlayersFlashed {
+		if flashed.FlasherSteamID64 != player.SteamID64 || flashed.IsFlasherControllingBot {
+			continue
+		}
+		if flashed.FlashedSide == flashed.FlasherSide {
+			continue
+		}
+		if stats, ok := sideStats[sideToString(flashed.FlasherSide)]; ok {
+			stats.EnemiesFlashed++
+		}
+	}
+
+	for _, clutch := range match.Clutches {
+		if clutch.ClutcherSteamID64 != player.SteamID64 || clutch.OpponentCount < 1 || clutch.OpponentCount > maxClutchOpponents {
+			continue
+		}
+		if stats, ok := sideStats[sideToString(clutch.Side)]; ok {
+			stats.ClutchAttempts[clutch.OpponentCount-1]++
+			if clutch.HasWon {
+				stats.ClutchWins[clutch.OpponentCount-1]++
+			}
+		}
+	}
 
 	for _, stats := range sideStats {
+		stats.FlashEfficiency = flashEfficiency(stats.EnemiesFlashed, stats.FlashesThrown)
+		stats.OpeningDuelWinRate = percentage(stats.FirstKills, stats.FirstKills+stats.FirstDeaths)
+
 		if stats.Deaths > 0 {
 			stats.KD = float64(stats.Kills) / float64(stats.Deaths)
 		} else if stats.Kills > 0 {
 			stats.KD = float64(stats.Kills)
 		}
+		stats.KillDeathDiffPerRound = killDeathDiffPerRound(stats.Kills, stats.Deaths, stats.RoundsPlayed)
 	}
 
 	// Calculate KAST for each side
-	sideStats["T"].KAST = calculateKASTForSide(match, player, common.TeamTerrorists)
-	sideStats["CT"].KAST = calculateKASTForSide(match, player, common.TeamCounterTerrorists)
+	for sideKey, side := range map[string]common.Team{"T": common.TeamTerrorists, "CT": common.TeamCounterTerrorists} {
+		stats := sideStats[sideKey]
+		var components kastComponents
+		stats.KAST, components = calculateKASTForSide(match, player, side)
+		stats.KASTViaKill = components.kill
+		stats.KASTViaAssist = components.assist
+		stats.KASTViaSurvive = components.survive
+		stats.KASTViaTrade = components.trade
+	}
+
+	sideStats["T"].RWS = calculateRWSForSide(match, player, common.TeamTerrorists)
+	sideStats["CT"].RWS = calculateRWSForSide(match, player, common.TeamCounterTerrorists)
 
 	return sideStats
 }
 
+// halfLength returns the rounds in a regulation half of match, e.g. 12 for
+// MR12. It is read from the half-time side switch when match has both
+// rounds around it. Otherwise regulation rounds past 24 mean MR15, as does
+// a MaxRounds of 30 or more without overtime: cs-demo-analyzer guesses
+// MaxRounds from the final score when the demo doesn't record
+// mp_maxrounds, so an MR12 overtime win would read as MR15.
+func halfLength(match *api.Match) int {
+	lastRegulation := 0
+	for i, round := range match.Rounds {
+		if round.OvertimeNumber != 0 {
+			continue
+		}
+		lastRegulation = max(lastRegulation, round.Number)
+		if i == 0 {
+			continue
+		}
+		prev := match.Rounds[i-1]
+		if round.Number == prev.Number+1 && round.TeamASide != prev.TeamASide {
+			return prev.Number
+		}
+	}
+	if lastRegulation > 2*mr12HalfLength || match.OvertimeCount == 0 && match.MaxRounds >= 2*mr15HalfLength {
+		return mr15HalfLength
+	}
+	return mr12HalfLength
+}
+
+// inRoundRange reports whether round number n lies within roundRange, where a
+// bound of 0 is unbounded.
+func inRoundRange(n int, roundRange [2]int) bool {
+	return (roundRange[0] == 0 || n >= roundRange[0]) && (roundRange[1] == 0 || n <= roundRange[1])
+}
+
+// restrictToRounds returns a shallow copy of match holding only the rounds,
+// and the per-round events used for stats, within roundRange. match itself is
+// left untouched so cached matches can be recomputed with another range.
+func restrictToRounds(match *api.Match, roundRange [2]int) *api.Match {
+	if roundRange == [2]int{} {
+		return match
+	}
+
+	restricted := *match
+	inRange := func(n int) bool { return inRoundRange(n, roundRange) }
+	restricted.Rounds = filterEvents(match.Rounds, func(r *api.Round) bool { return inRange(r.Number) })
+	restricted.Kills = filterEvents(match.Kills, func(k *api.Kill) bool { return inRange(k.RoundNumber) })
+	restricted.Damages = filterEvents(match.Damages, func(d *api.Damage) bool {
+		round := damageRound(match, d)
+		return round != nil && inRange(round.Number)
+	})
+	restricted.PlayersFlashed = filterEvents(match.PlayersFlashed, func(f *api.PlayerFlashed) bool { return inRange(f.RoundNumber) })
+	restricted.FlashbangsExplode = filterEvents(match.FlashbangsExplode, func(f *api.FlashbangExplode) bool { return inRange(f.RoundNumber) })
+	restricted.BombsPlanted = filterEvents(match.BombsPlanted, func(b *api.BombPlanted) bool { return inRange(b.RoundNumber) })
+	restricted.BombsDefused = filterEvents(match.BombsDefused, func(b *api.BombDefused) bool { return inRange(b.RoundNumber) })
+	restricted.Clutches = filterEvents(match.Clutches, func(c *api.Clutch) bool { return inRange(c.RoundNumber) })
+	return &restricted
+}
+
+// excludeAccounts returns a shallow copy of match without the kills, damage
+// and flashes involving any of the excluded SteamID64s, so bots or smurfs
+// don't count as opponents (or teammates) anywhere in the stats. A kill by an
+// excluded account is kept as a death without a killer: the victim still died,
+// and dropping it would count the round as survived for KAST.
+func excludeAccounts(match *api.Match, excluded map[uint64]bool) *api.Match {
+	if len(excluded) == 0 {
+		return match
+	}
+
+	filtered := *match
+	filtered.Kills = nil
+	for _, kill := range match.Kills {
+		switch {
+		case excluded[kill.VictimSteamID64]:
+			continue
+		case excluded[kill.KillerSteamID64]:
+			death := *kill
+			death.KillerSteamID64 = 0
+			death.KillerName = ""
+			death.KillerSide = common.TeamUnassigned
+			death.KillerTeamName = ""
+			death.IsKillerControllingBot = false
+			kill = &death
+		}
+		filtered.Kills = append(filtered.Kills, kill)
+	}
+	filtered.Damages = filterEvents(match.Damages, func(d *api.Damage) bool {
+		return !excluded[d.AttackerSteamID64] && !excluded[d.VictimSteamID64]
+	})
+	filtered.PlayersFlashed = filterEvents(match.PlayersFlashed, func(f *api.PlayerFlashed) bool {
+		return !excluded[f.FlasherSteamID64] && !excluded[f.FlashedSteamID64]
+	})
+	return &filtered
+}
+
+// withoutFlashAssists returns a shallow copy of match in which kills with a
+// flash assist credit no assister, so flash-only assists count toward neither
+// Assists nor KAST. The original kills are left untouched.
+func withoutFlashAssists(match *api.Match) *api.Match {
+	stripped := *match
+	stripped.Kills = make([]*api.Kill, len(match.Kills))
+	for i, kill := range match.Kills {
+		if !kill.IsAssistedFlash {
+			stripped.Kills[i] = kill
+			continue
+		}
+		k := *kill
+		k.AssisterSteamID64 = 0
+		k.AssisterName = ""
+		k.AssisterSide = common.TeamUnassigned
+		k.IsAssistedFlash = false
+		stripped.Kills[i] = &k
+	}
+	return &stripped
+}
+
+// filterEvents returns the events for which keep is true, in a new slice.
+func filterEvents[T any](events []T, keep func(T) bool) []T {
+	filtered := make([]T, 0, len(events))
+	for _, event := range events {
+		if keep(event) {
+			filtered = append(filtered, event)
+		}
+	}
+	return filtered
+}
+
+// killInvolvement counts the kill events steamID64 took part in as killer,
+// victim or assister, split by whether they were controlling a bot.
+func killInvolvement(match *api.Match, steamID64 uint64) (own, asBot int) {
+	count := func(id uint64, controllingBot bool) {
+		if id != steamID64 {
+			return
+		}
+		if controllingBot {
+			asBot++
+		} else {
+			own++
+		}
+	}
+	for _, kill := range match.Kills {
+		count(kill.KillerSteamID64, kill.IsKillerControllingBot)
+		count(kill.VictimSteamID64, kill.IsVictimControllingBot)
+		count(kill.AssisterSteamID64, kill.IsAssisterControllingBot)
+	}
+	return own, asBot
+}
+
+// playedAnyRound reports whether player was on T or CT in at least one round.
+func playedAnyRound(match *api.Match, player *api.Player) bool {
+	for _, round := range match.Rounds {
+		if sideToString(determinePlayerSideInRound(match, player, round)) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// openingKill returns the round's first kill by tick that is neither a
+// suicide nor a team kill, whoever made it, or nil if there is none. FK/FD
+// are then credited only if the tracked player is that kill's killer/victim.
+func openingKill(killsInRound []*api.Kill) *api.Kill {
+	sorted := append([]*api.Kill(nil), killsInRound...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Tick < sorted[j].Tick
+	})
+
+	for _, kill := range sorted {
+		if isValidFrag(kill) {
+			return kill
+		}
+	}
+	return nil
+}
+
+// damageRound returns the round damage was dealt in: the first round whose
+// ticks contain it, so damage on a boundary tick counts once, or else the
+// round with the damage's RoundNumber, for demos with imprecise round
+// boundaries. It returns nil if neither matches. Every stat matches damage
+// to rounds with it, so ADR, RWS and round ranges agree.
+func damageRound(match *api.Match, damage *api.Damage) *api.Round {
+	for _, round := range match.Rounds {
+		if damage.Tick >= round.StartTick && damage.Tick <= round.EndTick {
+			return round
+		}
+	}
+	return roundByNumber(match, damage.RoundNumber)
+}
+
+// roundByNumber returns the round numbered n, or nil if there is none.
+// Kills are attributed by their RoundNumber, like every per-round stat.
+func roundByNumber(match *api.Match, n int) *api.Round {
+	for _, round := range match.Rounds {
+		if round.Number == n {
+			return round
+		}
+	}
+	return nil
+}
+
+// damagePhase buckets a tick by seconds since the round's freeze time ended
+// (or the round start if freeze time end is unknown).
+func damagePhase(match *api.Match, round *api.Round, tick int) string {
+	tickRate := match.TickRate
+	if tickRate <= 0 {
+		tickRate = defaultTickRate
+	}
+
+	startTick := round.FreezeTimeEndTick
+	if startTick <= 0 {
+		startTick = round.StartTick
+	}
+
+	seconds := float64(tick-startTick) / tickRate
+	switch {
+	case seconds < 15:
+		return DamagePhaseEarly
+	case seconds < 40:
+		return DamagePhaseMid
+	default:
+		return DamagePhaseLate
+	}
+}
+
+// isValidFrag reports whether kill counts toward the killer's kills: it is
+// neither a suicide nor a team kill. The kills column, opening kills and
+// KAST all use it, so they agree on what a kill is.
+func isValidFrag(kill *api.Kill) bool {
+	return !kill.IsSuicide() && !kill.IsTeamKill()
+}
+
+// isValidAssist reports whether kill credits player with an assist. The
+// assister must be the player (not controlling a bot) on an assigned side that
+// is opposite to the victim's; an unassigned AssisterSide never counts.
+func isValidAssist(kill *api.Kill, player *api.Player) bool {
+	if kill.AssisterSteamID64 != player.SteamID64 || kill.IsAssisterControllingBot {
+		return false
+	}
+	if sideToString(kill.AssisterSide) == "" || sideToString(kill.VictimSide) == "" {
+		return false
+	}
+	return kill.AssisterSide != kill.VictimSide
+}
+
+// wasTradedByTeammate reports whether the killer in death was killed by one of
+// the victim's teammates within tradeWindowSeconds.
+func wasTradedByTeammate(match *api.Match, death *api.Kill) bool {
+	tickRate := match.TickRate
+	if tickRate <= 0 {
+		tickRate = defaultTickRate
+	}
+	windowTicks := int(tradeWindowSeconds * tickRate)
+
+	for _, kill := range match.Kills {
+		if kill.RoundNumber != death.RoundNumber || kill.VictimSteamID64 != death.KillerSteamID64 {
+			continue
+		}
+		if kill.Tick < death.Tick || kill.Tick-death.Tick > windowTicks {
+			continue
+		}
+		if kill.KillerSide == death.VictimSide && kill.KillerSteamID64 != death.VictimSteamID64 {
+			return true
+		}
+	}
+	return false
+}
+
+// countWeaponKills adds the player's kills in match to byWeapon, keyed by
+// weapon name, and byCategory, keyed by weapon category. Uses the same kill
+// filters as extractPlayerStatsBySide.
+func countWeaponKills(match *api.Match, player *api.Player, byWeapon, byCategory map[string]int) {
+	for _, kill := range match.Kills {
+		if kill.KillerSteamID64 != player.SteamID64 || kill.IsKillerControllingBot {
+			continue
+		}
+		if !isValidFrag(kill) {
+			continue
+		}
+		byWeapon[string(kill.WeaponName)]++
+		byCategory[classifyWeapon(kill.WeaponName)]++
+	}
+}
+
+// kastComponents counts the rounds in which each KAST condition held. A round
+// counts once for every condition it meets, so the counts can add up to more
+// than the number of KAST rounds.
+type kastComponents struct {
+	kill, assist, survive, trade int
+}
+
 // calculateKASTForSide calculates KAST percentage for a specific side.
 // KAST = (Kill or Assist or Survived or Traded) / Total Rounds
-func calculateKASTForSide(match *api.Match, player *api.Player, side common.Team) float64 {
-	kastPerRound := make(map[int]bool)
+func calculateKASTForSide(match *api.Match, player *api.Player, side common.Team) (float64, kastComponents) {
+	var components kastComponents
+	kastRounds := 0
 	roundsOnThisSide := 0
 
 	for _, round := range match.Rounds {
@@ -362,56 +1203,296 @@ func calculateKASTForSide(match *api.Match, player *api.Player, side common.Team
 		}
 
 		roundsOnThisSide++
-		kastPerRound[round.Number] = false
-		playerSurvived := true
+		gotKill, gotAssist, playerSurvived, wasTraded := kastInRound(match, player, round)
 
-		for _, kill := range match.Kills {
-			if round.Number != kill.RoundNumber {
-				continue
-			}
+		if gotKill {
+			components.kill++
+		}
+		if gotAssist {
+			components.assist++
+		}
+		if playerSurvived {
+			components.survive++
+		}
+		if wasTraded {
+			components.trade++
+		}
+		if gotKill || gotAssist || playerSurvived || wasTraded {
+			kastRounds++
+		}
+	}
 
-			isTeamKill := kill.KillerSide == kill.VictimSide
-			if isTeamKill {
-				continue
-			}
+	if roundsOnThisSide > 0 {
+		return (float64(kastRounds) / float64(roundsOnThisSide)) * 100.0, components
+	}
 
-			if kill.AssisterSteamID64 == player.SteamID64 {
-				kastPerRound[round.Number] = true
-			}
+	return 0.0, components
+}
+
+// kastInRound reports which KAST conditions player met in round: a kill, an
+// assist, surviving, or being traded. Kills, assists and deaths are counted
+// as in extractPlayerStatsBySide, so a round with a kill in the kills column
+// always meets K, and one with a death never meets S.
+func kastInRound(match *api.Match, player *api.Player, round *api.Round) (gotKill, gotAssist, survived, wasTraded bool) {
+	survived = true
+
+	for _, kill := range match.Kills {
+		if round.Number != kill.RoundNumber {
+			continue
+		}
 
-			if kill.KillerSteamID64 == player.SteamID64 && kill.VictimSteamID64 != player.SteamID64 {
-				kastPerRound[round.Number] = true
+		if isValidAssist(kill, player) {
+			gotAssist = true
+		}
+
+		if kill.KillerSteamID64 == player.SteamID64 && !kill.IsKillerControllingBot && isValidFrag(kill) {
+			gotKill = true
+		}
+
+		if kill.VictimSteamID64 == player.SteamID64 && !kill.IsVictimControllingBot && !kill.IsSuicide() {
+			survived = false
+			if kill.IsTradeDeath {
+				wasTraded = true
 			}
+		}
+	}
 
-			if kill.VictimSteamID64 == player.SteamID64 {
-				playerSurvived = false
-				if kill.IsTradeDeath {
-					kastPerRound[round.Number] = true
+	return gotKill, gotAssist, survived, wasTraded
+}
+
+// calculateRWSForSide calculates Round Win Share for a specific side.
+// Each won round is worth 100 points. If it ended on a bomb explosion or
+// defuse, the planter/defuser takes rwsObjectiveShare first; the remainder is
+// split among the winning team by health damage dealt that round. Lost rounds
+// score 0, and the result is averaged over all rounds played on the side.
+func calculateRWSForSide(match *api.Match, player *api.Player, side common.Team) float64 {
+	totalPoints := 0.0
+	roundsOnThisSide := 0
+
+	for _, round := range match.Rounds {
+		playerSide := determinePlayerSideInRound(match, player, round)
+		if playerSide != side {
+			continue
+		}
+
+		roundsOnThisSide++
+		if round.WinnerSide != side {
+			continue
+		}
+
+		damagePool := 100.0
+		switch round.EndReason {
+		case events.RoundEndReasonTargetBombed:
+			damagePool -= rwsObjectiveShare
+			for _, plant := range match.BombsPlanted {
+				if plant.RoundNumber == round.Number && plant.PlanterSteamID64 == player.SteamID64 {
+					totalPoints += rwsObjectiveShare
+				}
+			}
+		case events.RoundEndReasonBombDefused:
+			damagePool -= rwsObjectiveShare
+			for _, defuse := range match.BombsDefused {
+				if defuse.RoundNumber == round.Number && defuse.DefuserSteamID64 == player.SteamID64 {
+					totalPoints += rwsObjectiveShare
 				}
 			}
 		}
 
-		if playerSurvived {
-			kastPerRound[round.Number] = true
+		teamDamage := 0
+		playerDamage := 0
+		for _, damage := range match.Damages {
+			if damageRound(match, damage) != round {
+				continue
+			}
+			if damage.AttackerSide != side || damage.VictimSide == side {
+				continue
+			}
+			teamDamage += damage.HealthDamage
+			if damage.AttackerSteamID64 == player.SteamID64 {
+				playerDamage += damage.HealthDamage
+			}
 		}
-	}
 
-	kastEventCount := 0
-	for _, hasKASTEvent := range kastPerRound {
-		if hasKASTEvent {
-			kastEventCount++
+		if teamDamage > 0 {
+			totalPoints += damagePool * float64(playerDamage) / float64(teamDamage)
 		}
 	}
 
 	if roundsOnThisSide > 0 {
-		return (float64(kastEventCount) / float64(roundsOnThisSide)) * 100.0
+		return totalPoints / float64(roundsOnThisSide)
 	}
 
 	return 0.0
 }
 
+// matchAverages combines one match's side stats into round-weighted ADR and
+// KAST values. hasADR is false if neither side had damage data, and ok is
+// false if the player played no rounds.
+func matchAverages(sideStats map[string]*SideStatistics) (adr, kast float64, hasADR, ok bool) {
+	rounds, adrRounds := 0, 0
+	for _, stats := range sideStats {
+		rounds += stats.RoundsPlayed
+		kast += stats.KAST * float64(stats.RoundsPlayed)
+		adrRounds += stats.DamageRounds
+		adr += stats.ADR * float64(stats.DamageRounds)
+	}
+	if rounds == 0 {
+		return 0, 0, false, false
+	}
+	if adrRounds > 0 {
+		adr /= float64(adrRounds)
+	}
+	return adr, kast / float64(rounds), adrRounds > 0, true
+}
+
+// flashEfficiency returns enemies flashed per flashbang thrown, or 0 if no
+// flashbangs were thrown.
+func flashEfficiency(enemiesFlashed, flashesThrown int) float64 {
+	if flashesThrown == 0 {
+		return 0
+	}
+	return float64(enemiesFlashed) / float64(flashesThrown)
+}
+
+// killDeathDiffPerRound returns (kills - deaths) / rounds, or 0 with no rounds.
+func killDeathDiffPerRound(kills, deaths, rounds int) float64 {
+	if rounds == 0 {
+		return 0
+	}
+	return float64(kills-deaths) / float64(rounds)
+}
+
+// percentage returns part/total as a percentage, or 0 when total is 0.
+func percentage(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(part) / float64(total) * 100.0
+}
+
+// sum returns the total of values.
+func sum(values []int) int {
+	total := 0
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
+
+// median returns the middle value of samples, averaging the two middle values
+// for even counts. It returns 0 for no samples.
+func median(samples []float64) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), samples...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// mapKD returns the K/D across both sides of a map, counted like SideStatistics.KD.
+func mapKD(mapStats *MapStatistics) float64 {
+	kills, deaths := 0, 0
+	for _, sideStats := range mapStats.SideStats {
+		kills += sideStats.Kills
+		deaths += sideStats.Deaths
+	}
+	if deaths > 0 {
+		return float64(kills) / float64(deaths)
+	}
+	return float64(kills)
+}
+
+// disambiguateNames sets the DisplayName of players whose name is shared by
+// another tracked player to the name and the last digits of the SteamID64,
+// e.g. "John (…1234)", so their rows can be told apart.
+func disambiguateNames(players []*PlayerStats) {
+	counts := make(map[string]int)
+	for _, playerStats := range players {
+		if playerStats != nil && playerStats.PlayerName != "" {
+			counts[playerStats.PlayerName]++
+		}
+	}
+	for _, playerStats := range players {
+		if playerStats == nil {
+			continue
+		}
+		playerStats.DisplayName = ""
+		if counts[playerStats.PlayerName] < 2 {
+			continue
+		}
+		suffix := playerStats.SteamID64
+		if len(suffix) > 4 {
+			suffix = suffix[len(suffix)-4:]
+		}
+		playerStats.DisplayName = fmt.Sprintf("%s (…%s)", playerStats.PlayerName, suffix)
+	}
+}
+
+// topFraggersByMap picks the player(s) with the highest K/D on each map.
+// Players are listed in name order so ties read the same on every run.
+func topFraggersByMap(players []*PlayerStats) map[string]string {
+	type leader struct {
+		kd    float64
+		names []string
+	}
+	leaders := make(map[string]*leader)
+
+	for _, playerStats := range players {
+		name := playerStats.ShownName()
+		for mapName, mapStats := range playerStats.MapStats {
+			kd := mapKD(mapStats)
+			current := leaders[mapName]
+			switch {
+			case current == nil || kd > current.kd:
+				leaders[mapName] = &leader{kd: kd, names: []string{name}}
+			case kd == current.kd:
+				current.names = append(current.names, name)
+			}
+		}
+	}
+
+	result := make(map[string]string, len(leaders))
+	for mapName, l := range leaders {
+		sort.Strings(l.names)
+		result[mapName] = strings.Join(l.names, ", ")
+	}
+	return result
+}
+
+// calculateTeamStats aggregates players as if they were one player, for the
+// Team row: their map stats are pooled, so counts are summed and KAST and
+// ADR are averaged over all of their rounds (or, with AverageModeMedian, are
+// the medians of all their per-match values). MatchesPlayed counts each
+// match any of them played once. It returns nil for fewer than two players.
+func calculateTeamStats(players []*PlayerStats, averageMode string) *OverallStatistics {
+	if len(players) < 2 {
+		return nil
+	}
+
+	mapStats := make(map[string]*MapStatistics)
+	matches := make(map[MatchInfo]bool)
+	for _, playerStats := range players {
+		for mapName, mapStat := range playerStats.MapStats {
+			mapStats[playerStats.SteamID64+"/"+mapName] = mapStat
+			for _, matchStats := range mapStat.Matches {
+				matches[matchStats.MatchInfo] = true
+			}
+		}
+	}
+
+	team := calculateOverallStats(mapStats, averageMode)
+	team.MatchesPlayed = len(matches)
+	return team
+}
+
 // calculateOverallStats aggregates statistics across all maps and sides.
-func calculateOverallStats(mapStats map[string]*MapStatistics) *OverallStatistics {
+// With AverageModeMedian, ADR and KAST are the medians of per-match values.
+func calculateOverallStats(mapStats map[string]*MapStatistics, averageMode string) *OverallStatistics {
 	overall := &OverallStatistics{}
 
 	for _, mapStat := range mapStats {
@@ -421,29 +1502,57 @@ func calculateOverallStats(mapStats map[string]*MapStatistics) *OverallStatistic
 			overall.Kills += sideStat.Kills
 			overall.Deaths += sideStat.Deaths
 			overall.Assists += sideStat.Assists
+			overall.FlashAssists += sideStat.FlashAssists
 			overall.FirstKills += sideStat.FirstKills
 			overall.FirstDeaths += sideStat.FirstDeaths
 			overall.TradeKills += sideStat.TradeKills
 			overall.TradeDeaths += sideStat.TradeDeaths
+			overall.TimesTradedFor += sideStat.TimesTradedFor
+			overall.FlashesThrown += sideStat.FlashesThrown
+			overall.EnemiesFlashed += sideStat.EnemiesFlashed
+			overall.UtilityDamage += sideStat.UtilityDamage
 			overall.Headshots += sideStat.Headshots
 			overall.RoundsPlayed += sideStat.RoundsPlayed
+			overall.FirstKillRoundsWon += sideStat.FirstKillRoundsWon
+			overall.FirstDeathRoundsWon += sideStat.FirstDeathRoundsWon
+			overall.Kills2 += sideStat.Kills2
+			overall.Kills3 += sideStat.Kills3
+			overall.Kills4 += sideStat.Kills4
+			overall.Kills5 += sideStat.Kills5
+			overall.RoundsWon += sideStat.RoundsWon
+			overall.PistolRoundsPlayed += sideStat.PistolRoundsPlayed
+			overall.PistolRoundsWon += sideStat.PistolRoundsWon
+			overall.ClutchAttempts += sum(sideStat.ClutchAttempts[:])
+			overall.ClutchWins += sum(sideStat.ClutchWins[:])
 		}
 	}
 
+	overall.FlashEfficiency = flashEfficiency(overall.EnemiesFlashed, overall.FlashesThrown)
+	overall.OpeningKillRoundWinRate = percentage(overall.FirstKillRoundsWon, overall.FirstKills)
+	overall.OpeningDeathRoundWinRate = percentage(overall.FirstDeathRoundsWon, overall.FirstDeaths)
+	overall.KillDeathDiffPerRound = killDeathDiffPerRound(overall.Kills, overall.Deaths, overall.RoundsPlayed)
+	overall.ClutchWinRate = percentage(overall.ClutchWins, overall.ClutchAttempts)
+	overall.OpeningDuelWinRate = percentage(overall.FirstKills, overall.FirstKills+overall.FirstDeaths)
+	overall.RoundWinRate = percentage(overall.RoundsWon, overall.RoundsPlayed)
+	overall.PistolRoundWinRate = percentage(overall.PistolRoundsWon, overall.PistolRoundsPlayed)
+
 	if overall.Deaths > 0 {
 		overall.KD = float64(overall.Kills) / float64(overall.Deaths)
 	} else if overall.Kills > 0 {
 		overall.KD = float64(overall.Kills)
 	}
 
+	// Rounds without damage data would read as 0 ADR, so leave them out
 	totalDamage := 0.0
+	damageRounds := 0
 	for _, mapStat := range mapStats {
 		for _, sideStat := range mapStat.SideStats {
-			totalDamage += sideStat.ADR * float64(sideStat.RoundsPlayed)
+			totalDamage += sideStat.ADR * float64(sideStat.DamageRounds)
+			damageRounds += sideStat.DamageRounds
 		}
 	}
-	if overall.RoundsPlayed > 0 {
-		overall.ADR = totalDamage / float64(overall.RoundsPlayed)
+	if damageRounds > 0 {
+		overall.ADR = totalDamage / float64(damageRounds)
 	}
 
 	kastRoundsTotal := 0.0
@@ -456,5 +1565,25 @@ func calculateOverallStats(mapStats map[string]*MapStatistics) *OverallStatistic
 		overall.KAST = (kastRoundsTotal / float64(overall.RoundsPlayed)) * 100.0
 	}
 
+	rwsTotal := 0.0
+	for _, mapStat := range mapStats {
+		for _, sideStat := range mapStat.SideStats {
+			rwsTotal += sideStat.RWS * float64(sideStat.RoundsPlayed)
+		}
+	}
+	if overall.RoundsPlayed > 0 {
+		overall.RWS = rwsTotal / float64(overall.RoundsPlayed)
+	}
+
+	if averageMode == AverageModeMedian {
+		var adrSamples, kastSamples []float64
+		for _, mapStat := range mapStats {
+			adrSamples = append(adrSamples, mapStat.ADRSamples...)
+			kastSamples = append(kastSamples, mapStat.KASTSamples...)
+		}
+		overall.ADR = median(adrSamples)
+		overall.KAST = median(kastSamples)
+	}
+
 	return overall
 }
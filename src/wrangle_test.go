@@ -0,0 +1,684 @@
+package manalyzer
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akiver/cs-demo-analyzer/pkg/api"
+	"github.com/markus-wa/demoinfocs-golang/v4/pkg/demoinfocs/common"
+	"github.com/markus-wa/demoinfocs-golang/v4/pkg/demoinfocs/events"
+)
+
+// addEconomy records steamID64 as playing round n on side.
+func addEconomy(match *api.Match, n int, steamID64 uint64, side common.Team) {
+	match.PlayerEconomies = append(match.PlayerEconomies, &api.PlayerEconomy{
+		RoundNumber: n,
+		Name:        testPlayerNames[steamID64],
+		SteamID64:   steamID64,
+		PlayerSide:  side,
+	})
+}
+
+func TestSidesFollowEachRound(t *testing.T) {
+	match := newTestMatch("de_mirage", 24)
+	addKill(match, 1, 500, alice, carol)
+	addKill(match, 13, 500, alice, carol)
+	addKill(match, 14, 500, carol, alice)
+	result := processTestMatches(t, []*api.Match{match}, alice)
+
+	sides := testPlayerStats(t, result, alice).MapStats["de_mirage"].SideStats
+	if ct := sides["CT"]; ct.RoundsPlayed != 12 || ct.Kills != 1 || ct.Deaths != 0 {
+		t.Errorf("CT: %d rounds, %d kills, %d deaths; want 12, 1, 0", ct.RoundsPlayed, ct.Kills, ct.Deaths)
+	}
+	if tSide := sides["T"]; tSide.RoundsPlayed != 12 || tSide.Kills != 1 || tSide.Deaths != 1 {
+		t.Errorf("T: %d rounds, %d kills, %d deaths; want 12, 1, 1", tSide.RoundsPlayed, tSide.Kills, tSide.Deaths)
+	}
+}
+
+func TestSidesFollowEconomy(t *testing.T) {
+	match := newTestMatch("de_mirage", 24)
+	// Alice joins in round 5 and plays T for the rest of the first half,
+	// then CT: the opposite of her team's sides.
+	for n := 5; n <= 24; n++ {
+		side := common.TeamTerrorists
+		if n > mr12HalfLength {
+			side = common.TeamCounterTerrorists
+		}
+		addEconomy(match, n, alice, side)
+	}
+	addKill(match, 2, 500, alice, carol)
+	addKill(match, 6, 500, alice, bob)
+	addKill(match, 20, 500, alice, bob)
+	addDamage(match, 3, 400, alice, carol, 100)
+	addDamage(match, 6, 400, alice, bob, 100)
+	result := processTestMatches(t, []*api.Match{match}, alice)
+
+	sides := testPlayerStats(t, result, alice).MapStats["de_mirage"].SideStats
+	if tSide := sides["T"]; tSide.RoundsPlayed != 8 || tSide.Kills != 1 || tSide.ADR != 100.0/8 {
+		t.Errorf("T: %d rounds, %d kills, %.2f ADR; want 8, 1, 12.50", tSide.RoundsPlayed, tSide.Kills, tSide.ADR)
+	}
+	if ct := sides["CT"]; ct.RoundsPlayed != 12 || ct.Kills != 1 || ct.ADR != 0 {
+		t.Errorf("CT: %d rounds, %d kills, %.2f ADR; want 12, 1, 0", ct.RoundsPlayed, ct.Kills, ct.ADR)
+	}
+}
+
+func TestExcludeSteamIDs(t *testing.T) {
+	match := newTestMatch("de_mirage", 3)
+	addKill(match, 1, 500, alice, dave)
+	addDamage(match, 1, 400, alice, dave, 100)
+	addKill(match, 2, 500, dave, alice)
+	addKill(match, 3, 500, alice, carol)
+	addDamage(match, 3, 400, alice, carol, 60)
+
+	prefs := testPreferences()
+	prefs.ExcludeSteamIDs = []string{"not a SteamID", strconv.FormatUint(dave, 10)}
+	result, err := ProcessMatches(context.Background(), []*api.Match{match}, []string{strconv.FormatUint(alice, 10)}, prefs, [2]int{})
+	if err != nil {
+		t.Fatalf("ProcessMatches: %v", err)
+	}
+
+	ct := testPlayerStats(t, result, alice).MapStats["de_mirage"].SideStats["CT"]
+	if ct.Kills != 1 || ct.ADR != 20 {
+		t.Errorf("%d kills, %.2f ADR; want 1, 20 without Dave", ct.Kills, ct.ADR)
+	}
+	// Dying to Dave still counts, so round 2 is not survived.
+	if ct.Deaths != 1 || ct.KASTViaSurvive != 2 {
+		t.Errorf("%d deaths, survived %d rounds; want 1, 2", ct.Deaths, ct.KASTViaSurvive)
+	}
+}
+
+func TestDamageRound(t *testing.T) {
+	// Round 2 starts on the tick round 1 ends on; round 3 starts after a gap.
+	match := &api.Match{Rounds: []*api.Round{
+		{Number: 1, StartTick: 0, EndTick: 1000},
+		{Number: 2, StartTick: 1000, EndTick: 2000},
+		{Number: 3, StartTick: 2500, EndTick: 3500},
+	}}
+	tests := []struct {
+		name   string
+		damage api.Damage
+		want   int // Round number, 0 for none
+	}{
+		{"inside", api.Damage{Tick: 500, RoundNumber: 1}, 1},
+		{"boundary tick", api.Damage{Tick: 1000, RoundNumber: 2}, 1},
+		{"last tick", api.Damage{Tick: 3500, RoundNumber: 3}, 3},
+		{"between rounds", api.Damage{Tick: 2200, RoundNumber: 3}, 3},
+		{"after the last round", api.Damage{Tick: 4000, RoundNumber: 2}, 2},
+		{"no round", api.Damage{Tick: 2200, RoundNumber: 4}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := 0
+			if round := damageRound(match, &tt.damage); round != nil {
+				got = round.Number
+			}
+			if got != tt.want {
+				t.Errorf("damageRound = round %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDamageRoundAgreesAcrossStats(t *testing.T) {
+	// Alice's damage is dealt during round 1 but labelled round 2
+	match := newTestMatch("de_mirage", 2)
+	addDamage(match, 1, 300, alice, carol, 100).RoundNumber = 2
+	addDamage(match, 2, 300, bob, carol, 100)
+
+	for _, tt := range []struct {
+		roundRange [2]int
+		adr, rws   float64
+	}{
+		{[2]int{}, 50, 50},       // All of round 1's win share, none of round 2's
+		{[2]int{1, 1}, 100, 100}, // The range keeps the damage with round 1
+		{[2]int{2, 2}, 0, 0},
+	} {
+		result, err := ProcessMatches(context.Background(), []*api.Match{match},
+			[]string{strconv.FormatUint(alice, 10)}, testPreferences(), tt.roundRange)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ct := testPlayerStats(t, result, alice).MapStats["de_mirage"].SideStats["CT"]
+		if ct.ADR != tt.adr || ct.RWS != tt.rws {
+			t.Errorf("rounds %v: ADR %v and RWS %v, want %v and %v", tt.roundRange, ct.ADR, ct.RWS, tt.adr, tt.rws)
+		}
+	}
+}
+
+func TestOpeningKillUsesEarliestTick(t *testing.T) {
+	match := newTestMatch("de_mirage", 1)
+	// Listed out of order, as the analyzer can emit them.
+	addKill(match, 1, 800, alice, carol)
+	opener := addKill(match, 1, 200, dave, bob)
+
+	if got := openingKill(match.Kills); got != opener {
+		t.Errorf("openingKill = kill at tick %d, want tick %d", got.Tick, opener.Tick)
+	}
+
+	result := processTestMatches(t, []*api.Match{match}, alice, bob)
+	if ct := testPlayerStats(t, result, alice).MapStats["de_mirage"].SideStats["CT"]; ct.FirstKills != 0 {
+		t.Errorf("alice has %d opening kills, want 0", ct.FirstKills)
+	}
+	if ct := testPlayerStats(t, result, bob).MapStats["de_mirage"].SideStats["CT"]; ct.FirstDeaths != 1 {
+		t.Errorf("bob has %d opening deaths, want 1", ct.FirstDeaths)
+	}
+}
+
+func TestKASTAgreesWithKillsAndDeaths(t *testing.T) {
+	match := newTestMatch("de_mirage", 5)
+	addKill(match, 1, 500, alice, bob)   // Team kill: not a kill
+	addKill(match, 2, 500, alice, alice) // Suicide: neither a kill nor a death
+	addDamage(match, 3, 400, 0, alice, 30)
+	addKill(match, 3, 500, 0, alice)   // The world: a death
+	addKill(match, 4, 500, bob, alice) // Team killed: a death
+	addKill(match, 5, 500, alice, carol)
+	result := processTestMatches(t, []*api.Match{match}, alice)
+
+	ct := testPlayerStats(t, result, alice).MapStats["de_mirage"].SideStats["CT"]
+	if ct.Kills != 1 || ct.Deaths != 2 {
+		t.Fatalf("%d kills, %d deaths; want 1, 2", ct.Kills, ct.Deaths)
+	}
+	if ct.KASTViaKill != ct.Kills {
+		t.Errorf("KAST counts %d kill rounds for %d kills", ct.KASTViaKill, ct.Kills)
+	}
+	if died := ct.RoundsPlayed - ct.KASTViaSurvive; died != ct.Deaths {
+		t.Errorf("KAST counts %d rounds died for %d deaths", died, ct.Deaths)
+	}
+	if ct.ADR != 0 {
+		t.Errorf("ADR = %.2f from world damage, want 0", ct.ADR)
+	}
+}
+
+func TestSharedNamesAreDisambiguated(t *testing.T) {
+	match := newTestMatch("de_mirage", 3)
+	match.PlayersBySteamID[alice].Name = "John"
+	match.PlayersBySteamID[bob].Name = "John"
+	result := processTestMatches(t, []*api.Match{match}, alice, bob, carol)
+
+	want := map[uint64]string{alice: "John (…0001)", bob: "John (…0002)", carol: "carol"}
+	for steamID64, shown := range want {
+		playerStats := testPlayerStats(t, result, steamID64)
+		if got := playerStats.ShownName(); got != shown {
+			t.Errorf("%d is shown as %q, want %q", steamID64, got, shown)
+		}
+		if name := match.PlayersBySteamID[steamID64].Name; playerStats.PlayerName != name {
+			t.Errorf("%d PlayerName = %q, want the real name %q", steamID64, playerStats.PlayerName, name)
+		}
+	}
+
+	// Saved results keep the real names and show the suffix again on load
+	path := filepath.Join(t.TempDir(), "result.json")
+	if err := SaveResult(result, path, time.UTC); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved savedResult
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatal(err)
+	}
+	for _, playerStats := range saved.Result.PlayerStats {
+		if strings.Contains(playerStats.PlayerName, "…") {
+			t.Errorf("saved %s with the display name %q", playerStats.SteamID64, playerStats.PlayerName)
+		}
+	}
+	loaded, err := LoadResult(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := testPlayerStats(t, loaded, bob).ShownName(); got != want[bob] {
+		t.Errorf("loaded bob is shown as %q, want %q", got, want[bob])
+	}
+}
+
+func TestMedianAverageMode(t *testing.T) {
+	// Alice's ADR is 10, 10 and 70 in three equally long matches
+	var matches []*api.Match
+	for _, health := range []int{10, 10, 70} {
+		match := newTestMatch("de_mirage", 24)
+		for n := 1; n <= 24; n++ {
+			addDamage(match, n, 300, alice, testOpponent(match, alice, n), health)
+		}
+		matches = append(matches, match)
+	}
+
+	tests := []struct {
+		mode string
+		want float64
+	}{
+		{AverageModeMean, 30},
+		{AverageModeMedian, 10},
+	}
+	for _, tt := range tests {
+		prefs := testPreferences()
+		prefs.AverageMode = tt.mode
+		result, err := ProcessMatches(context.Background(), matches, []string{strconv.FormatUint(alice, 10)}, prefs, [2]int{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := testPlayerStats(t, result, alice).OverallStats.ADR; !closeTo(got, tt.want) {
+			t.Errorf("%s ADR = %v, want %v", tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestRoundWinShare(t *testing.T) {
+	// Alice and Bob play CT: they win round 1 on damage, round 2 on Alice's
+	// defuse and lose round 3.
+	match := newTestMatch("de_mirage", 3)
+	addDamage(match, 1, 300, alice, carol, 75)
+	addDamage(match, 1, 300, bob, dave, 25)
+	addDamage(match, 2, 300, bob, dave, 50)
+	match.Rounds[1].EndReason = events.RoundEndReasonBombDefused
+	match.BombsDefused = append(match.BombsDefused, &api.BombDefused{RoundNumber: 2, DefuserSteamID64: alice})
+	match.Rounds[2].WinnerSide = common.TeamTerrorists
+	addDamage(match, 3, 300, alice, carol, 100)
+
+	result := processTestMatches(t, []*api.Match{match}, alice, bob)
+	want := map[uint64]float64{
+		alice: (75 + rwsObjectiveShare) / 3,
+		bob:   (25 + 100 - rwsObjectiveShare) / 3,
+	}
+	for steamID64, rws := range want {
+		if got := testPlayerStats(t, result, steamID64).OverallStats.RWS; !closeTo(got, rws) {
+			t.Errorf("%d RWS = %v, want %v", steamID64, got, rws)
+		}
+	}
+}
+
+func TestMapVariantsShareABucket(t *testing.T) {
+	for _, name := range []string{"workshop/123456/de_mirage", `workshop\123456\DE_Mirage.bsp`, " De_Mirage "} {
+		if got := normalizeMapName(name); got != "de_mirage" {
+			t.Errorf("normalizeMapName(%q) = %q, want de_mirage", name, got)
+		}
+	}
+
+	matches := []*api.Match{newTestMatch("workshop/123456/de_mirage", 24), newTestMatch("DE_Mirage", 24)}
+	playerStats := testPlayerStats(t, processTestMatches(t, matches, alice), alice)
+	if len(playerStats.MapStats) != 1 || playerStats.MapStats["de_mirage"] == nil {
+		t.Fatalf("maps = %v, want only de_mirage", slices.Collect(maps.Keys(playerStats.MapStats)))
+	}
+	if got := playerStats.MapStats["de_mirage"].MatchesPlayed; got != 2 {
+		t.Errorf("de_mirage has %d matches, want 2", got)
+	}
+}
+
+func TestOpeningRoundWinRates(t *testing.T) {
+	// Alice plays CT. She opens rounds 1, 2 and 6 with a kill and dies first
+	// in rounds 3 and 4; Bob opens round 5. T wins rounds 2 and 4.
+	match := newTestMatch("de_mirage", 6)
+	for _, n := range []int{1, 2, 6} {
+		addKill(match, n, 300, alice, carol)
+	}
+	for _, n := range []int{3, 4} {
+		addKill(match, n, 300, carol, alice)
+	}
+	addKill(match, 5, 300, bob, dave)
+	addKill(match, 5, 400, alice, carol)
+	for _, n := range []int{2, 4} {
+		match.Rounds[n-1].WinnerSide = common.TeamTerrorists
+	}
+
+	overall := testPlayerStats(t, processTestMatches(t, []*api.Match{match}, alice), alice).OverallStats
+	if overall.FirstKills != 3 || overall.FirstKillRoundsWon != 2 {
+		t.Errorf("won %d of %d rounds with a first kill, want 2 of 3", overall.FirstKillRoundsWon, overall.FirstKills)
+	}
+	if overall.FirstDeaths != 2 || overall.FirstDeathRoundsWon != 1 {
+		t.Errorf("won %d of %d rounds with a first death, want 1 of 2", overall.FirstDeathRoundsWon, overall.FirstDeaths)
+	}
+	if !closeTo(overall.OpeningKillRoundWinRate, 200.0/3) || overall.OpeningDeathRoundWinRate != 50 {
+		t.Errorf("win rates after first kill %v and death %v, want 66.7 and 50", overall.OpeningKillRoundWinRate, overall.OpeningDeathRoundWinRate)
+	}
+}
+
+func TestTimesTradedFor(t *testing.T) {
+	// At 64 ticks a second, the trade window is 320 ticks
+	match := newTestMatch("de_mirage", 3)
+	addKill(match, 1, 300, carol, alice)
+	addKill(match, 1, 600, bob, carol) // Traded
+	addKill(match, 2, 300, dave, alice)
+	addKill(match, 2, 700, bob, dave) // Too late
+	addKill(match, 3, 300, carol, alice)
+	addKill(match, 3, 400, 0, carol) // Not by a teammate
+
+	overall := testPlayerStats(t, processTestMatches(t, []*api.Match{match}, alice), alice).OverallStats
+	if overall.Deaths != 3 || overall.TimesTradedFor != 1 {
+		t.Errorf("traded for %d of %d deaths, want 1 of 3", overall.TimesTradedFor, overall.Deaths)
+	}
+}
+
+func TestFlashEfficiency(t *testing.T) {
+	// Alice throws two flashes as CT: the first blinds Carol, Dave and her
+	// teammate Bob, the second nobody. A flash from a bot she controls
+	// doesn't count.
+	match := newTestMatch("de_mirage", 2)
+	for n := 1; n <= 2; n++ {
+		match.FlashbangsExplode = append(match.FlashbangsExplode, &api.FlashbangExplode{
+			RoundNumber:      n,
+			ThrowerSteamID64: alice,
+			ThrowerSide:      testSide(match, alice, n),
+		})
+	}
+	flashed := func(victim uint64, bot bool) {
+		match.PlayersFlashed = append(match.PlayersFlashed, &api.PlayerFlashed{
+			RoundNumber:             1,
+			FlashedSteamID64:        victim,
+			FlashedSide:             testSide(match, victim, 1),
+			FlasherSteamID64:        alice,
+			FlasherSide:             testSide(match, alice, 1),
+			IsFlasherControllingBot: bot,
+		})
+	}
+	flashed(carol, false)
+	flashed(dave, false)
+	flashed(bob, false)
+	flashed(carol, true)
+
+	overall := testPlayerStats(t, processTestMatches(t, []*api.Match{match}, alice), alice).OverallStats
+	if overall.FlashesThrown != 2 || overall.EnemiesFlashed != 2 || overall.FlashEfficiency != 1 {
+		t.Errorf("flashed %d enemies with %d flashes (%v per flash), want 2 with 2 (1)",
+			overall.EnemiesFlashed, overall.FlashesThrown, overall.FlashEfficiency)
+	}
+}
+
+func TestIsValidAssist(t *testing.T) {
+	player := &api.Player{SteamID64: alice}
+	ct, ts, none := common.TeamCounterTerrorists, common.TeamTerrorists, common.TeamUnassigned
+	tests := []struct {
+		name string
+		kill api.Kill
+		want bool
+	}{
+		{"opposite sides", api.Kill{AssisterSteamID64: alice, AssisterSide: ct, VictimSide: ts}, true},
+		{"same side", api.Kill{AssisterSteamID64: alice, AssisterSide: ct, VictimSide: ct}, false},
+		{"unassigned assister", api.Kill{AssisterSteamID64: alice, AssisterSide: none, VictimSide: ts}, false},
+		{"unassigned victim", api.Kill{AssisterSteamID64: alice, AssisterSide: ct, VictimSide: none}, false},
+		{"spectator assister", api.Kill{AssisterSteamID64: alice, AssisterSide: common.TeamSpectators, VictimSide: ts}, false},
+		{"controlling a bot", api.Kill{AssisterSteamID64: alice, AssisterSide: ct, VictimSide: ts, IsAssisterControllingBot: true}, false},
+		{"someone else", api.Kill{AssisterSteamID64: bob, AssisterSide: ct, VictimSide: ts}, false},
+	}
+	for _, tt := range tests {
+		if got := isValidAssist(&tt.kill, player); got != tt.want {
+			t.Errorf("%s: isValidAssist = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDamageByPhase(t *testing.T) {
+	// At 16 ticks a second freeze time ends 100 ticks, 6.25s, into a round
+	match := newTestMatch("de_mirage", 2)
+	match.TickRate = 16
+	addDamage(match, 1, 50, alice, carol, 1)          // Before freeze time ends
+	addDamage(match, 1, 100+10*16, alice, carol, 10)  // 10s
+	addDamage(match, 1, 100+15*16, alice, carol, 20)  // 15s
+	addDamage(match, 2, 100+39*16, alice, carol, 30)  // 39s
+	addDamage(match, 2, 100+45*16, alice, dave, 40)   // 45s
+	addDamage(match, 2, 100+45*16, carol, alice, 100) // Taken, not dealt
+
+	ct := testPlayerStats(t, processTestMatches(t, []*api.Match{match}, alice), alice).MapStats["de_mirage"].SideStats["CT"]
+	want := map[string]int{DamagePhaseEarly: 11, DamagePhaseMid: 50, DamagePhaseLate: 40}
+	if !maps.Equal(ct.DamageByPhase, want) {
+		t.Errorf("DamageByPhase = %v, want %v", ct.DamageByPhase, want)
+	}
+}
+
+func TestSpectatedDemoIsReported(t *testing.T) {
+	played, spectated := newTestMatch("de_mirage", 24), newTestMatch("de_mirage", 24)
+	spectated.PlayersBySteamID[alice].Team = &api.Team{Name: "Spectators"}
+	result := processTestMatches(t, []*api.Match{played, spectated}, alice)
+
+	want := []string{"alice spectated " + spectated.DemoFileName}
+	if !slices.Equal(result.Diagnostics, want) {
+		t.Errorf("Diagnostics = %q, want %q", result.Diagnostics, want)
+	}
+	if got := testPlayerStats(t, result, alice).MapStats["de_mirage"].MatchesPlayed; got != 1 {
+		t.Errorf("MatchesPlayed = %d, want only the played demo", got)
+	}
+}
+
+func TestTopFraggerByMap(t *testing.T) {
+	mirage, nuke, inferno := newTestMatch("de_mirage", 3), newTestMatch("de_nuke", 3), newTestMatch("de_inferno", 3)
+	addKill(mirage, 1, 300, alice, carol)
+	addKill(mirage, 2, 300, alice, dave)
+	addKill(mirage, 3, 300, bob, carol)
+	addKill(mirage, 3, 400, dave, bob)
+	addKill(nuke, 1, 300, bob, carol)
+	addKill(nuke, 1, 400, bob, dave)
+	addKill(nuke, 2, 300, alice, carol)
+	addKill(nuke, 2, 400, carol, alice)
+	addKill(inferno, 1, 300, bob, carol)
+	addKill(inferno, 2, 300, alice, carol)
+
+	result := processTestMatches(t, []*api.Match{mirage, nuke, inferno}, bob, alice)
+	want := map[string]string{"de_mirage": "alice", "de_nuke": "bob", "de_inferno": "alice, bob"}
+	if !maps.Equal(result.TopFraggerByMap, want) {
+		t.Errorf("TopFraggerByMap = %v, want %v", result.TopFraggerByMap, want)
+	}
+}
+
+func TestRoundRangeFirstHalf(t *testing.T) {
+	match := newTestMatch("de_mirage", 24)
+	for n := 1; n <= 24; n++ {
+		addKill(match, n, 300, alice, testOpponent(match, alice, n))
+	}
+	result, err := ProcessMatches(context.Background(), []*api.Match{match},
+		[]string{strconv.FormatUint(alice, 10)}, testPreferences(), [2]int{1, mr12HalfLength})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Alice's team starts as CT, so the first half is all CT
+	overall := testPlayerStats(t, result, alice).OverallStats
+	if overall.RoundsPlayed != 12 || overall.Kills != 12 {
+		t.Errorf("%d rounds and %d kills in the first half, want 12 and 12", overall.RoundsPlayed, overall.Kills)
+	}
+	if tSide := testPlayerStats(t, result, alice).MapStats["de_mirage"].SideStats["T"]; tSide != nil && tSide.RoundsPlayed > 0 {
+		t.Errorf("T side has %d rounds in the first half", tSide.RoundsPlayed)
+	}
+	if len(match.Rounds) != 24 || len(match.Kills) != 24 {
+		t.Errorf("the cached match was changed: %d rounds, %d kills", len(match.Rounds), len(match.Kills))
+	}
+}
+
+func TestKASTBreakdown(t *testing.T) {
+	// Round 1 is KAST only by survival, Alice dies untraded in round 2 and
+	// kills and survives in round 3
+	match := newTestMatch("de_mirage", 3)
+	addKill(match, 2, 300, carol, alice)
+	addKill(match, 3, 300, alice, carol)
+
+	ct := testPlayerStats(t, processTestMatches(t, []*api.Match{match}, alice), alice).MapStats["de_mirage"].SideStats["CT"]
+	if !closeTo(ct.KAST, 200.0/3) {
+		t.Errorf("KAST = %v, want 66.7", ct.KAST)
+	}
+	got := [4]int{ct.KASTViaKill, ct.KASTViaAssist, ct.KASTViaSurvive, ct.KASTViaTrade}
+	if want := [4]int{1, 0, 2, 0}; got != want {
+		t.Errorf("KAST via kill, assist, survival and trade = %v, want %v", got, want)
+	}
+}
+
+func TestADRSkipsSideWithoutDamageData(t *testing.T) {
+	// The demo only has damage events for the first half, Alice's CT side
+	match := newTestMatch("de_mirage", 24)
+	for n := 1; n <= mr12HalfLength; n++ {
+		addDamage(match, n, 300, alice, carol, 60)
+	}
+	playerStats := testPlayerStats(t, processTestMatches(t, []*api.Match{match}, alice), alice)
+
+	sides := playerStats.MapStats["de_mirage"].SideStats
+	if !sides["CT"].HasDamageData || sides["T"].HasDamageData {
+		t.Errorf("HasDamageData is %v for CT and %v for T, want true and false", sides["CT"].HasDamageData, sides["T"].HasDamageData)
+	}
+	if adr := playerStats.OverallStats.ADR; adr != 60 {
+		t.Errorf("overall ADR = %v, want 60 from the CT side alone", adr)
+	}
+}
+
+func TestADRSkipsMatchWithoutDamageData(t *testing.T) {
+	// Only the first demo has damage events; the second must not dilute ADR
+	damaged, undamaged := newTestMatch("de_mirage", 24), newTestMatch("de_mirage", 24)
+	for n := 1; n <= mr12HalfLength; n++ {
+		addDamage(damaged, n, 300, alice, carol, 100)
+	}
+	result := processTestMatches(t, []*api.Match{damaged, undamaged}, alice)
+	playerStats := testPlayerStats(t, result, alice)
+
+	ct := playerStats.MapStats["de_mirage"].SideStats["CT"]
+	if ct.ADR != 100 || ct.DamageRounds != mr12HalfLength || ct.RoundsPlayed != 2*mr12HalfLength {
+		t.Errorf("CT ADR = %v over %d of %d rounds, want 100 over %d of %d",
+			ct.ADR, ct.DamageRounds, ct.RoundsPlayed, mr12HalfLength, 2*mr12HalfLength)
+	}
+	if adr := playerStats.OverallStats.ADR; adr != 100 {
+		t.Errorf("overall ADR = %v, want 100 from the damaged match alone", adr)
+	}
+	chart, err := buildChart(result, ChartMapBreakdown)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := chart.groups[0].values; !slices.Equal(got, []float64{100}) {
+		t.Errorf("map breakdown ADR = %v, want [100]", got)
+	}
+}
+
+func TestFilterMatchesByRoster(t *testing.T) {
+	// Each match has a different subset of the tracked players
+	all, withoutBob, onlyAlice := newTestMatch("de_mirage", 1), newTestMatch("de_nuke", 1), newTestMatch("de_inferno", 1)
+	delete(withoutBob.PlayersBySteamID, bob)
+	for _, steamID64 := range []uint64{bob, carol} {
+		delete(onlyAlice.PlayersBySteamID, steamID64)
+	}
+	matches := []*api.Match{all, withoutBob, onlyAlice}
+	roster := []string{strconv.FormatUint(alice, 10), strconv.FormatUint(bob, 10), strconv.FormatUint(carol, 10), "not a SteamID"}
+
+	tests := []struct {
+		minPresent int
+		want       []*api.Match
+	}{
+		{0, matches},
+		{1, matches},
+		{2, []*api.Match{all, withoutBob}},
+		{3, []*api.Match{all}},
+		{4, []*api.Match{}}, // The invalid SteamID never counts
+	}
+	for _, tt := range tests {
+		kept, dropped := FilterMatchesByRoster(matches, roster, tt.minPresent)
+		if !slices.Equal(kept, tt.want) || dropped != len(matches)-len(tt.want) {
+			t.Errorf("at least %d present: kept %d and dropped %d matches, want %d kept", tt.minPresent, len(kept), dropped, len(tt.want))
+		}
+	}
+}
+
+func TestKillDeathDiffPerRound(t *testing.T) {
+	// Four kills and five deaths in ten rounds; a suicide counts as neither
+	match := newTestMatch("de_mirage", 10)
+	for n := 1; n <= 4; n++ {
+		addKill(match, n, 300, alice, carol)
+	}
+	for n := 5; n <= 9; n++ {
+		addKill(match, n, 300, dave, alice)
+	}
+	addKill(match, 10, 300, alice, alice)
+
+	overall := testPlayerStats(t, processTestMatches(t, []*api.Match{match}, alice), alice).OverallStats
+	if overall.KillDeathDiffPerRound != -0.1 {
+		t.Errorf("+/- = %v with %d kills and %d deaths, want -0.1", overall.KillDeathDiffPerRound, overall.Kills, overall.Deaths)
+	}
+	if got := killDeathDiffPerRound(3, 1, 0); got != 0 {
+		t.Errorf("+/- without rounds = %v, want 0", got)
+	}
+}
+
+func TestCountFlashAssists(t *testing.T) {
+	// Alice flash-assists Bob in round 1 and then dies, so the assist is her
+	// only KAST condition; in round 2 she assists with damage and survives.
+	match := newTestMatch("de_mirage", 2)
+	flashAssist := addKill(match, 1, 300, bob, carol)
+	flashAssist.AssisterSteamID64, flashAssist.AssisterSide, flashAssist.IsAssistedFlash = alice, testSide(match, alice, 1), true
+	addKill(match, 1, 400, dave, alice)
+	damageAssist := addKill(match, 2, 300, bob, carol)
+	damageAssist.AssisterSteamID64, damageAssist.AssisterSide = alice, testSide(match, alice, 2)
+
+	tests := []struct {
+		count                 bool
+		assists, flashAssists int
+		kast                  float64
+	}{
+		{true, 2, 1, 100},
+		{false, 1, 0, 50},
+	}
+	for _, tt := range tests {
+		prefs := testPreferences()
+		prefs.CountFlashAssists = tt.count
+		result, err := ProcessMatches(context.Background(), []*api.Match{match}, []string{strconv.FormatUint(alice, 10)}, prefs, [2]int{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		overall := testPlayerStats(t, result, alice).OverallStats
+		if overall.Assists != tt.assists || overall.FlashAssists != tt.flashAssists || overall.KAST != tt.kast {
+			t.Errorf("CountFlashAssists %v: %d assists, %d flash assists, KAST %v; want %d, %d, %v",
+				tt.count, overall.Assists, overall.FlashAssists, overall.KAST, tt.assists, tt.flashAssists, tt.kast)
+		}
+	}
+	if !flashAssist.IsAssistedFlash || flashAssist.AssisterSteamID64 != alice {
+		t.Error("leaving out flash assists changed the cached match")
+	}
+}
+
+func TestOpeningDuelsByPhase(t *testing.T) {
+	// At 16 ticks a second freeze time ends 100 ticks into a round. Alice
+	// plays CT in round 1 and T in round 13.
+	match := newTestMatch("de_mirage", 14)
+	match.TickRate = 16
+	addKill(match, 1, 100+5*16, alice, carol)   // Early, won
+	addKill(match, 2, 100+20*16, dave, alice)   // Mid, lost
+	addKill(match, 3, 100+10*16, bob, carol)    // Not her duel
+	addKill(match, 3, 100+12*16, alice, dave)   // After the opening
+	addKill(match, 13, 100+50*16, carol, alice) // Late, lost
+	addKill(match, 14, 100+30*16, alice, dave)  // Mid, won
+
+	sides := testPlayerStats(t, processTestMatches(t, []*api.Match{match}, alice), alice).MapStats["de_mirage"].SideStats
+	want := map[string]map[string]int{
+		"CT": {DamagePhaseEarly: 1, DamagePhaseMid: 1},
+		"T":  {DamagePhaseMid: 1, DamagePhaseLate: 1},
+	}
+	for side, phases := range want {
+		if got := sides[side].OpeningDuelsByPhase; !maps.Equal(got, phases) {
+			t.Errorf("%s OpeningDuelsByPhase = %v, want %v", side, got, phases)
+		}
+	}
+}
+
+func TestBotControlledOnlyPlayer(t *testing.T) {
+	match := newTestMatch("de_mirage", 24)
+	addKill(match, 1, 300, alice, carol)
+	addKill(match, 2, 300, dave, bob).IsKillerControllingBot = true
+	addKill(match, 3, 300, alice, dave).IsVictimControllingBot = true
+	// bob only loses control when killed, so some of his events are his own.
+	addKill(match, 4, 300, carol, bob).IsVictimControllingBot = true
+
+	result := processTestMatches(t, []*api.Match{match}, alice, bob, dave)
+	for side, stats := range testPlayerStats(t, result, dave).MapStats["de_mirage"].SideStats {
+		if stats.Kills != 0 {
+			t.Errorf("dave has %d %s kills, want 0 as all were bot-controlled", stats.Kills, side)
+		}
+	}
+	var warned []string
+	for _, diagnostic := range result.Diagnostics {
+		if strings.Contains(diagnostic, "controlling a bot") {
+			warned = append(warned, diagnostic)
+		}
+	}
+	if len(warned) != 1 || !strings.Contains(warned[0], strconv.FormatUint(dave, 10)) {
+		t.Errorf("bot warnings = %q, want one for dave only", warned)
+	}
+}